@@ -0,0 +1,17 @@
+package brc20
+
+import (
+	"web/utils/decimal"
+)
+
+// parseAmount 严格解析正数数量，小数位不超过 dec
+func parseAmount(s string, dec uint8) (decimal.Amount, error) {
+	a, err := decimal.NewAmount(s, dec)
+	if err != nil {
+		return decimal.Amount{}, err
+	}
+	if !a.IsPositive() {
+		return decimal.Amount{}, decimal.ErrFormat
+	}
+	return a, nil
+}
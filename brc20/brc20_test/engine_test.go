@@ -0,0 +1,138 @@
+package brc20_test
+
+import (
+	"testing"
+	"web/brc20"
+	"web/config"
+	"web/constant"
+)
+
+const height = constant.FIRST_BRC20_Block
+
+func TestDeployMint(t *testing.T) {
+	e := brc20.NewEngine()
+	rs := e.ApplyBlock(height, []brc20.Event{
+		{Op: constant.BRC20_OP_DEPLOY, Tick: "ordi", Max: "100", Lim: "60", Dec: "2"},
+		{Op: constant.BRC20_OP_DEPLOY, Tick: "ORDI", Max: "100"},
+		{Op: constant.BRC20_OP_DEPLOY, Tick: "abc", Max: "100"},
+		{Op: constant.BRC20_OP_MINT, Tick: "ordi", To: "a", Amt: "60"},
+		{Op: constant.BRC20_OP_MINT, Tick: "ordi", To: "a", Amt: "61"},
+		{Op: constant.BRC20_OP_MINT, Tick: "Ordi", To: "b", Amt: "50.5"},
+		{Op: constant.BRC20_OP_MINT, Tick: "ordi", To: "b", Amt: "1"},
+		{Op: constant.BRC20_OP_MINT, Tick: "ordi", To: "b", Amt: "1.001"},
+		{Op: constant.BRC20_OP_MINT, Tick: "sats", To: "b", Amt: "1"},
+	})
+
+	want := []int{
+		constant.BRC20_VALID_VALID,
+		constant.BRC20_VALID_INVALID,
+		constant.BRC20_VALID_WRONG,
+		constant.BRC20_VALID_VALID,
+		constant.BRC20_VALID_INVALID,
+		constant.BRC20_VALID_VALID,
+		constant.BRC20_VALID_INVALID,
+		constant.BRC20_VALID_WRONG,
+		constant.BRC20_VALID_INVALID,
+	}
+	for i, r := range rs {
+		if r.Valid != want[i] {
+			t.Errorf("event %d: want %d got %d (%s)", i, want[i], r.Valid, r.Reason)
+		}
+	}
+	// 超出剩余供应量时截断
+	if rs[5].Amount != "40" {
+		t.Errorf("partial mint amount %s", rs[5].Amount)
+	}
+	if b := e.Balance("ordi", "b"); b.Available.String() != "40" {
+		t.Errorf("unexpected balance %s", b.Available)
+	}
+}
+
+func TestTransferSend(t *testing.T) {
+	e := brc20.NewEngine()
+	e.ApplyBlock(height, []brc20.Event{
+		{Op: constant.BRC20_OP_DEPLOY, Tick: "ordi", Max: "1000"},
+		{Op: constant.BRC20_OP_MINT, Tick: "ordi", To: "a", Amt: "100"},
+	})
+
+	rs := e.ApplyBlock(height+1, []brc20.Event{
+		{Op: constant.BRC20_OP_TRANSFER, Tick: "ordi", To: "a", Amt: "30", InscriptionID: "t1"},
+		{Op: constant.BRC20_OP_TRANSFER, Tick: "ordi", To: "a", Amt: "80", InscriptionID: "t2"},
+		{Op: constant.BRC20_OP_SEND, InscriptionID: "t1", From: "a", To: "b"},
+		{Op: constant.BRC20_OP_SEND, InscriptionID: "t1", From: "b", To: "c"},
+	})
+
+	if rs[0].Valid != constant.BRC20_VALID_VALID || rs[0].Transferable != "30" || rs[0].Available != "70" {
+		t.Fatalf("unexpected transfer %+v", rs[0])
+	}
+	if rs[1].Valid != constant.BRC20_VALID_INVALID {
+		t.Fatalf("transfer over available should be invalid %+v", rs[1])
+	}
+	if rs[2].Valid != constant.BRC20_VALID_VALID || rs[2].Tick != "ordi" || rs[2].Available != "30" {
+		t.Fatalf("unexpected send %+v", rs[2])
+	}
+	if rs[3].Valid != constant.BRC20_VALID_INVALID {
+		t.Fatalf("used transfer inscription should be invalid %+v", rs[3])
+	}
+	if b := e.Balance("ordi", "a"); b.Available.String() != "70" || !b.Transferable.IsZero() {
+		t.Fatalf("unexpected sender balance %+v", b)
+	}
+}
+
+func TestDuplicateTransfer(t *testing.T) {
+	e := brc20.NewEngine()
+	e.ApplyBlock(height, []brc20.Event{
+		{Op: constant.BRC20_OP_DEPLOY, Tick: "ordi", Max: "1000"},
+		{Op: constant.BRC20_OP_MINT, Tick: "ordi", To: "a", Amt: "100"},
+	})
+
+	rs := e.ApplyBlock(height+1, []brc20.Event{
+		{Op: constant.BRC20_OP_TRANSFER, Tick: "ordi", To: "a", Amt: "30", InscriptionID: "t1"},
+		{Op: constant.BRC20_OP_TRANSFER, Tick: "ordi", To: "a", Amt: "20", InscriptionID: "t1"},
+	})
+	if rs[1].Valid != constant.BRC20_VALID_INVALID || rs[1].Reason != brc20.ReasonDuplicate {
+		t.Fatalf("duplicate transfer inscription should be invalid %+v", rs[1])
+	}
+	// 第一次的数量仍然可以转移
+	if b := e.Balance("ordi", "a"); b.Available.String() != "70" || b.Transferable.String() != "30" {
+		t.Fatalf("unexpected balance %+v", b)
+	}
+	rs = e.ApplyBlock(height+2, []brc20.Event{{Op: constant.BRC20_OP_SEND, InscriptionID: "t1", From: "a", To: "b"}})
+	if rs[0].Valid != constant.BRC20_VALID_VALID || rs[0].Amount != "30" {
+		t.Fatalf("unexpected send %+v", rs[0])
+	}
+}
+
+func TestBeforeActivation(t *testing.T) {
+	rs := brc20.NewEngine().ApplyBlock(height-1, []brc20.Event{
+		{Op: constant.BRC20_OP_DEPLOY, Tick: "ordi", Max: "1000"},
+	})
+	if rs[0].Valid != constant.BRC20_VALID_INVALID {
+		t.Fatalf("unexpected result %+v", rs[0])
+	}
+}
+
+func TestCursedJubilee(t *testing.T) {
+	deploy := brc20.Event{Op: constant.BRC20_OP_DEPLOY, Tick: "ordi", Max: "1000", InscriptionNumber: -1}
+
+	rs := brc20.NewEngine(brc20.WithJubileeHeight(height+10)).ApplyBlock(height+9, []brc20.Event{deploy})
+	if rs[0].Valid != constant.BRC20_VALID_CURSED {
+		t.Fatalf("cursed before jubilee: %+v", rs[0])
+	}
+
+	rs = brc20.NewEngine(brc20.WithJubileeHeight(height+10)).ApplyBlock(height+10, []brc20.Event{deploy})
+	if rs[0].Valid != constant.BRC20_VALID_VALID {
+		t.Fatalf("cursed at jubilee: %+v", rs[0])
+	}
+}
+
+func TestConfigOptions(t *testing.T) {
+	e := brc20.NewEngine(brc20.ConfigOptions(config.Brc20{FirstHeight: 100, JubileeHeight: 200})...)
+	rs := e.ApplyBlock(150, []brc20.Event{
+		{Op: constant.BRC20_OP_DEPLOY, Tick: "ordi", Max: "1000"},
+		{Op: constant.BRC20_OP_DEPLOY, Tick: "sats", Max: "1000", InscriptionNumber: -5},
+	})
+	if rs[0].Valid != constant.BRC20_VALID_VALID || rs[1].Valid != constant.BRC20_VALID_CURSED {
+		t.Fatalf("unexpected results %+v", rs)
+	}
+}
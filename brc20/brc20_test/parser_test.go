@@ -0,0 +1,141 @@
+package brc20_test
+
+import (
+	"errors"
+	"testing"
+	"web/brc20"
+	"web/constant"
+)
+
+func TestParseInscription(t *testing.T) {
+	ins, err := brc20.ParseInscription([]byte(`{"p":"brc-20","op":"mint","tick":"ORDI","amt":"1000"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ins.OpCode != constant.BRC20_OP_N_MINT || ins.TickLower != "ordi" || ins.Amt != "1000" {
+		t.Fatalf("unexpected inscription %+v", ins)
+	}
+
+	// tick 长度按 UTF-8 字节计算，转小写按 Unicode 规则
+	ins, err = brc20.ParseInscription([]byte(`{"p":"brc-20","op":"deploy","tick":"ÖRD","max":"21000000","lim":"1000"}`))
+	if err != nil || ins.OpCode != constant.BRC20_OP_N_DEPLOY || ins.TickLower != "örd" {
+		t.Fatalf("unexpected deploy %+v %v", ins, err)
+	}
+	if _, err = brc20.ParseInscription([]byte(`{"p":"brc-20","op":"mint","tick":"中文","amt":"1"}`)); err == nil {
+		t.Fatal("6-byte tick should be rejected")
+	}
+}
+
+func TestParseInscriptionErrors(t *testing.T) {
+	cases := map[string]string{
+		`not json`:   brc20.ErrCodeJSON,
+		`["brc-20"]`: brc20.ErrCodeNotObject,
+		`{"p":"brc-20","op":"mint","tick":"ordi","amt":"1","amt":"2"}`:    brc20.ErrCodeDuplicateKey,
+		`{"p":"brc-20","op":"mint","tick":"ordi","amt":1}`:                brc20.ErrCodeNotString,
+		`{"p":"brc20","op":"mint","tick":"ordi","amt":"1"}`:               brc20.ErrCodeProtocol,
+		`{"p":"brc-20","op":"burn","tick":"ordi","amt":"1"}`:              brc20.ErrCodeOp,
+		`{"p":"brc-20","op":"mint","amt":"1"}`:                            brc20.ErrCodeTickMissing,
+		`{"p":"brc-20","op":"mint","tick":"ord","amt":"1"}`:               brc20.ErrCodeTickLength,
+		`{"p":"brc-20","op":"deploy","tick":"ordis","max":"1"}`:           brc20.ErrCodeSelfMint,
+		`{"p":"brc-20","op":"mint","tick":"ordi"}`:                        brc20.ErrCodeAmtMissing,
+		`{"p":"brc-20","op":"mint","tick":"ordi","amt":"1e3"}`:            brc20.ErrCodeAmtFormat,
+		`{"p":"brc-20","op":"mint","tick":"ordi","amt":"+1"}`:             brc20.ErrCodeAmtFormat,
+		`{"p":"brc-20","op":"deploy","tick":"ordi"}`:                      brc20.ErrCodeMaxMissing,
+		`{"p":"brc-20","op":"deploy","tick":"ordi","max":"1","dec":"19"}`: brc20.ErrCodeDecFormat,
+		`{"p":"brc-20","op":"mint","tick":"ordi","amt":"1"} {}`:           brc20.ErrCodeUnexpectedData,
+	}
+	for content, code := range cases {
+		_, err := brc20.ParseInscription([]byte(content))
+		var pe *brc20.ParseError
+		if !errors.As(err, &pe) || pe.Code != code {
+			t.Errorf("%s: want %s got %v", content, code, err)
+		}
+	}
+}
+
+func TestSelfMint(t *testing.T) {
+	ins, err := brc20.ParseInscription([]byte(`{"p":"brc-20","op":"deploy","tick":"ordis","max":"0","self_mint":"true"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	deploy := ins.Event()
+	deploy.InscriptionID = "d0"
+
+	e := brc20.NewEngine(brc20.WithSelfMintHeight(height + 1))
+	rs := e.ApplyBlock(height, []brc20.Event{deploy})
+	if rs[0].Valid != constant.BRC20_VALID_WRONG {
+		t.Fatalf("5-byte tick before activation: %+v", rs[0])
+	}
+
+	rs = e.ApplyBlock(height+1, []brc20.Event{
+		deploy,
+		{Op: constant.BRC20_OP_MINT, Tick: "ordis", To: "a", Amt: "1"},
+		{Op: constant.BRC20_OP_MINT, Tick: "ordis", To: "a", Amt: "1", Parent: "d0"},
+	})
+	if rs[0].Valid != constant.BRC20_VALID_VALID || rs[1].Valid != constant.BRC20_VALID_INVALID || rs[2].Valid != constant.BRC20_VALID_VALID {
+		t.Fatalf("unexpected self mint results %+v", rs)
+	}
+}
+
+func TestEngineContent(t *testing.T) {
+	e := brc20.NewEngine()
+	rs := e.ApplyBlock(height, []brc20.Event{
+		{Content: `{"p":"brc-20","op":"deploy","tick":"ORDI","max":"100"}`, InscriptionID: "d"},
+		{Content: `{"p":"brc-20","op":"mint","tick":"ordi","amt":"10"}`, To: "a"},
+		{Content: `{"p":"brc-20","op":"mint","tick":"ordi","amt":"1","amt":"2"}`, To: "a"},
+	})
+	if rs[0].Valid != constant.BRC20_VALID_VALID || rs[0].Tick != "ORDI" || rs[0].Content != "" {
+		t.Fatalf("unexpected deploy %+v", rs[0])
+	}
+	if rs[1].Valid != constant.BRC20_VALID_VALID || e.Balance("ordi", "a").Available.String() != "10" {
+		t.Fatalf("unexpected mint %+v", rs[1])
+	}
+	if rs[2].Valid != constant.BRC20_VALID_WRONG || rs[2].Code != brc20.ErrCodeDuplicateKey || rs[2].To != "a" {
+		t.Fatalf("unexpected invalid mint %+v", rs[2])
+	}
+}
+
+func TestTickKey(t *testing.T) {
+	for tick, want := range map[string]string{
+		"ORDI":  "ordi",
+		"İabc":  "i̇abc",
+		"ΑΣ":    "ας",
+		"ΣΑ":    "σα",
+		"ΑΣ.Β":  "ασ.β",
+		"ΑΣ'":   "ας'",
+		"Σ":     "σ",
+		"abcİ!": "abci̇!",
+	} {
+		if got := brc20.TickKey(tick); got != want {
+			t.Errorf("TickKey(%q) = %q, want %q", tick, got, want)
+		}
+	}
+}
+
+func TestDottedCapitalITick(t *testing.T) {
+	// İabc 是 5 字节的 self_mint tick，不能与 4 字节的 iabc 冲突
+	ins, err := brc20.ParseInscription([]byte(`{"p":"brc-20","op":"deploy","tick":"İabc","max":"100","self_mint":"true"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	deploy := ins.Event()
+	deploy.InscriptionID = "d1"
+
+	e := brc20.NewEngine(brc20.WithSelfMintHeight(height))
+	rs := e.ApplyBlock(height, []brc20.Event{
+		{Op: constant.BRC20_OP_DEPLOY, Tick: "iabc", Max: "100", InscriptionID: "d0"},
+		deploy,
+		{Op: constant.BRC20_OP_MINT, Tick: "İabc", To: "a", Amt: "5", Parent: "d1"},
+	})
+	for i, r := range rs {
+		if r.Valid != constant.BRC20_VALID_VALID {
+			t.Fatalf("event %d: %+v", i, r)
+		}
+	}
+	if b := e.Balance("iabc", "a"); !b.Available.IsZero() {
+		t.Fatalf("mint of İabc credited iabc %+v", b)
+	}
+	if b := e.Balance("İabc", "a"); b.Available.String() != "5" {
+		t.Fatalf("unexpected İabc balance %+v", b)
+	}
+}
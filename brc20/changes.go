@@ -0,0 +1,80 @@
+package brc20
+
+// BalanceChange 修改后的地址余额
+type BalanceChange struct {
+	Tick    string
+	Address string
+	Balance
+}
+
+// Changes 上次 Flush 之后修改过的状态（修改后的值）
+type Changes struct {
+	Tokens        []Token
+	Balances      []BalanceChange
+	Transferables []Transferable
+	// 已经被 send 使用、需要删除的 transfer 铭文
+	Removed []string
+}
+
+type balanceKey struct {
+	tick    string
+	address string
+}
+
+type dirtySet struct {
+	tokens        map[string]struct{}
+	balances      map[balanceKey]struct{}
+	transferables map[string]struct{}
+}
+
+func newDirtySet() dirtySet {
+	return dirtySet{
+		tokens:        make(map[string]struct{}),
+		balances:      make(map[balanceKey]struct{}),
+		transferables: make(map[string]struct{}),
+	}
+}
+
+func (d dirtySet) markBalance(tick, address string) {
+	d.balances[balanceKey{tick: TickKey(tick), address: address}] = struct{}{}
+}
+
+// Flush 返回上次 Flush 之后修改过的状态并清空记录，一般每个区块调用一次
+func (e *Engine) Flush() Changes {
+	var c Changes
+	for k := range e.dirty.tokens {
+		c.Tokens = append(c.Tokens, *e.tokens[k])
+	}
+	for k := range e.dirty.balances {
+		c.Balances = append(c.Balances, BalanceChange{
+			Tick:    k.tick,
+			Address: k.address,
+			Balance: *e.balances[k.tick][k.address],
+		})
+	}
+	for id := range e.dirty.transferables {
+		if tr, ok := e.transferables[id]; ok {
+			c.Transferables = append(c.Transferables, *tr)
+			continue
+		}
+		c.Removed = append(c.Removed, id)
+	}
+
+	e.dirty = newDirtySet()
+	return c
+}
+
+// RestoreToken 从持久化的状态恢复代币，不记录为修改
+func (e *Engine) RestoreToken(t Token) {
+	e.tokens[TickKey(t.Tick)] = &t
+}
+
+// RestoreBalance 从持久化的状态恢复余额，不记录为修改
+func (e *Engine) RestoreBalance(tick, address string, b Balance) {
+	*e.balance(tick, address) = b
+}
+
+// RestoreTransferable 从持久化的状态恢复 transfer 铭文，不记录为修改
+func (e *Engine) RestoreTransferable(tr Transferable) {
+	e.transferables[tr.InscriptionID] = &tr
+}
@@ -0,0 +1,345 @@
+package brc20
+
+import (
+	"sort"
+	"strconv"
+	"web/constant"
+	"web/utils/decimal"
+)
+
+/*
+	brc20 按区块顺序处理 BRC-20 事件并维护代币、余额状态
+	1. deploy：tick 为 4 字节，max 必填，lim 默认等于 max，dec 默认 18（0 - 18）
+	   self_mint 激活后允许 5 字节 tick，deploy 必须带 self_mint，max 为 0 表示不限量，
+	   mint 必须以 deploy 铭文为父铭文
+	2. mint：数量不超过 lim，超过剩余供应量时截断到剩余量，已经 mint 完则无效
+	3. transfer：铭刻时从可用余额转入可转账余额
+	4. send：transfer 铭文被转移时，从所有者的可转账余额转入接收方可用余额，
+	   接收方为空（作为手续费花掉）时退回所有者
+	5. jubilee 高度之前铭刻的 cursed（编号为负）铭文不生效，结果为 BRC20_VALID_CURSED
+*/
+
+const (
+	tickLength = 4
+	maxDec     = 18
+	// self_mint 代币 max 为 0 表示不限量
+	unlimitedMax = "0"
+)
+
+// 无效原因
+const (
+	ReasonBeforeActivation = "before activation height"
+	ReasonCursed           = "cursed inscription before jubilee"
+	ReasonUnknownOp        = "unknown op"
+	ReasonTickLength       = "tick length"
+	ReasonSelfMint         = "5-byte tick requires self_mint"
+	ReasonSelfMintParent   = "self_mint requires deploy inscription as parent"
+	ReasonTickExists       = "tick already deployed"
+	ReasonTickNotExists    = "tick not deployed"
+	ReasonMax              = "invalid max"
+	ReasonLim              = "invalid lim"
+	ReasonDec              = "invalid dec"
+	ReasonAmt              = "invalid amt"
+	ReasonOverLimit        = "amt over lim"
+	ReasonMintedOut        = "minted out"
+	ReasonInsufficient     = "insufficient available balance"
+	ReasonNoTransferable   = "transfer inscription not exists or used"
+	ReasonDuplicate        = "transfer inscription already exists"
+	ReasonArithmetic       = "arithmetic error"
+)
+
+type Engine struct {
+	opts Options
+
+	tokens        map[string]*Token
+	balances      map[string]map[string]*Balance
+	transferables map[string]*Transferable
+
+	// 上次 Flush 之后修改过的状态
+	dirty dirtySet
+}
+
+func NewEngine(opts ...Option) *Engine {
+	return &Engine{
+		opts:          newOptions(opts...),
+		tokens:        make(map[string]*Token),
+		balances:      make(map[string]map[string]*Balance),
+		transferables: make(map[string]*Transferable),
+		dirty:         newDirtySet(),
+	}
+}
+
+// ApplyBlock 按顺序处理区块内的事件，每个事件返回一个结果
+func (e *Engine) ApplyBlock(height uint, events []Event) []Result {
+	results := make([]Result, 0, len(events))
+	for _, ev := range events {
+		results = append(results, e.apply(height, ev))
+	}
+	return results
+}
+
+// Token 返回已部署的代币，tick 不区分大小写
+func (e *Engine) Token(tick string) (Token, bool) {
+	t, ok := e.tokens[TickKey(tick)]
+	if !ok {
+		return Token{}, false
+	}
+	return *t, true
+}
+
+// Balance 返回地址在代币上的余额
+func (e *Engine) Balance(tick, address string) Balance {
+	if b, ok := e.balances[TickKey(tick)][address]; ok {
+		return *b
+	}
+	return Balance{}
+}
+
+// PendingTransfers 返回尚未被 send 的 transfer 铭文 ID，按 ID 排序
+func (e *Engine) PendingTransfers() []string {
+	ret := make([]string, 0, len(e.transferables))
+	for id := range e.transferables {
+		ret = append(ret, id)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+func (e *Engine) apply(height uint, ev Event) Result {
+	if height < e.opts.firstHeight {
+		return invalid(ev, constant.BRC20_VALID_INVALID, ReasonBeforeActivation)
+	}
+	// send 针对的是已经铭刻的 transfer 铭文，铭刻时已经判断过
+	if ev.Op != constant.BRC20_OP_SEND && ev.InscriptionNumber < 0 && height < e.opts.jubileeHeight {
+		return invalid(ev, constant.BRC20_VALID_CURSED, ReasonCursed)
+	}
+	if ev.Op != constant.BRC20_OP_SEND && ev.Content != "" {
+		parsed, err := parseContent(ev)
+		if err != nil {
+			r := invalid(ev, constant.BRC20_VALID_WRONG, err.Error())
+			if pe, ok := err.(*ParseError); ok {
+				r.Code = pe.Code
+			}
+			return r
+		}
+		ev = parsed
+	}
+	if ev.Op != constant.BRC20_OP_SEND && !e.tickLengthValid(height, ev.Tick) {
+		return invalid(ev, constant.BRC20_VALID_WRONG, ReasonTickLength)
+	}
+
+	switch ev.Op {
+	case constant.BRC20_OP_DEPLOY:
+		return e.deploy(height, ev)
+	case constant.BRC20_OP_MINT:
+		return e.mint(ev)
+	case constant.BRC20_OP_TRANSFER:
+		return e.transfer(ev)
+	case constant.BRC20_OP_SEND:
+		return e.send(ev)
+	}
+	return invalid(ev, constant.BRC20_VALID_WRONG, ReasonUnknownOp)
+}
+
+func (e *Engine) deploy(height uint, ev Event) Result {
+	if _, ok := e.tokens[TickKey(ev.Tick)]; ok {
+		return invalid(ev, constant.BRC20_VALID_INVALID, ReasonTickExists)
+	}
+
+	dec := uint8(maxDec)
+	if ev.Dec != "" {
+		d, err := strconv.ParseUint(ev.Dec, 10, 8)
+		if err != nil || d > maxDec {
+			return invalid(ev, constant.BRC20_VALID_WRONG, ReasonDec)
+		}
+		dec = uint8(d)
+	}
+
+	selfMint := len(ev.Tick) == selfMintTickLength
+	if selfMint && !ev.SelfMint {
+		return invalid(ev, constant.BRC20_VALID_WRONG, ReasonSelfMint)
+	}
+
+	max, err := parseAmount(ev.Max, dec)
+	if selfMint && ev.Max == unlimitedMax {
+		max, err = decimal.NewAmount(constant.UINT64_MAX_S, dec)
+	}
+	if err != nil {
+		return invalid(ev, constant.BRC20_VALID_WRONG, ReasonMax)
+	}
+	lim := max
+	if ev.Lim != "" {
+		if lim, err = parseAmount(ev.Lim, dec); err != nil {
+			return invalid(ev, constant.BRC20_VALID_WRONG, ReasonLim)
+		}
+	}
+
+	e.tokens[TickKey(ev.Tick)] = &Token{
+		Tick:          ev.Tick,
+		Max:           max,
+		Lim:           lim,
+		Dec:           dec,
+		Minted:        decimal.Zero(dec),
+		InscriptionID: ev.InscriptionID,
+		DeployHeight:  height,
+		SelfMint:      selfMint,
+	}
+	e.dirty.tokens[TickKey(ev.Tick)] = struct{}{}
+	return valid(ev, decimal.Zero(dec))
+}
+
+func (e *Engine) mint(ev Event) Result {
+	t, ok := e.tokens[TickKey(ev.Tick)]
+	if !ok {
+		return invalid(ev, constant.BRC20_VALID_INVALID, ReasonTickNotExists)
+	}
+
+	if t.SelfMint && ev.Parent != t.InscriptionID {
+		return invalid(ev, constant.BRC20_VALID_INVALID, ReasonSelfMintParent)
+	}
+
+	amt, err := parseAmount(ev.Amt, t.Dec)
+	if err != nil {
+		return invalid(ev, constant.BRC20_VALID_WRONG, ReasonAmt)
+	}
+	if amt.Cmp(t.Lim) > 0 {
+		return invalid(ev, constant.BRC20_VALID_INVALID, ReasonOverLimit)
+	}
+
+	left, err := t.Max.Sub(t.Minted)
+	if err != nil {
+		return invalid(ev, constant.BRC20_VALID_WRONG, ReasonArithmetic)
+	}
+	if !left.IsPositive() {
+		return invalid(ev, constant.BRC20_VALID_INVALID, ReasonMintedOut)
+	}
+	amt = decimal.Min(amt, left)
+
+	b := e.balance(ev.Tick, ev.To)
+	minted, err1 := t.Minted.Add(amt)
+	available, err2 := b.Available.Add(amt)
+	if err1 != nil || err2 != nil {
+		return invalid(ev, constant.BRC20_VALID_WRONG, ReasonArithmetic)
+	}
+	t.Minted = minted
+	b.Available = available
+	e.dirty.tokens[TickKey(ev.Tick)] = struct{}{}
+	e.dirty.markBalance(ev.Tick, ev.To)
+
+	return e.withBalance(valid(ev, amt), ev.To)
+}
+
+func (e *Engine) transfer(ev Event) Result {
+	t, ok := e.tokens[TickKey(ev.Tick)]
+	if !ok {
+		return invalid(ev, constant.BRC20_VALID_INVALID, ReasonTickNotExists)
+	}
+	// 同一铭文 ID 不能覆盖尚未转移的 transfer 铭文
+	if _, ok = e.transferables[ev.InscriptionID]; ok {
+		return invalid(ev, constant.BRC20_VALID_INVALID, ReasonDuplicate)
+	}
+
+	amt, err := parseAmount(ev.Amt, t.Dec)
+	if err != nil {
+		return invalid(ev, constant.BRC20_VALID_WRONG, ReasonAmt)
+	}
+
+	// transfer 铭文铭刻在 To 地址上
+	owner := ev.To
+	b := e.balance(ev.Tick, owner)
+	if b.Available.Cmp(amt) < 0 {
+		return invalid(ev, constant.BRC20_VALID_INVALID, ReasonInsufficient)
+	}
+
+	available, err1 := b.Available.Sub(amt)
+	transferable, err2 := b.Transferable.Add(amt)
+	if err1 != nil || err2 != nil {
+		return invalid(ev, constant.BRC20_VALID_WRONG, ReasonArithmetic)
+	}
+	b.Available = available
+	b.Transferable = transferable
+	e.transferables[ev.InscriptionID] = &Transferable{
+		InscriptionID: ev.InscriptionID,
+		Tick:          t.Tick,
+		Owner:         owner,
+		Amount:        amt,
+	}
+	e.dirty.markBalance(ev.Tick, owner)
+	e.dirty.transferables[ev.InscriptionID] = struct{}{}
+
+	return e.withBalance(valid(ev, amt), owner)
+}
+
+func (e *Engine) send(ev Event) Result {
+	tr, ok := e.transferables[ev.InscriptionID]
+	if !ok {
+		return invalid(ev, constant.BRC20_VALID_INVALID, ReasonNoTransferable)
+	}
+
+	ev.Tick = tr.Tick
+	ev.From = tr.Owner
+	to := ev.To
+	if to == "" {
+		to = tr.Owner
+	}
+
+	from := e.balance(tr.Tick, tr.Owner)
+	b := e.balance(tr.Tick, to)
+	transferable, err1 := from.Transferable.Sub(tr.Amount)
+	available, err2 := b.Available.Add(tr.Amount)
+	if err1 != nil || err2 != nil {
+		return invalid(ev, constant.BRC20_VALID_WRONG, ReasonArithmetic)
+	}
+	from.Transferable = transferable
+	b.Available = available
+	delete(e.transferables, ev.InscriptionID)
+	e.dirty.markBalance(tr.Tick, tr.Owner)
+	e.dirty.markBalance(tr.Tick, to)
+	e.dirty.transferables[ev.InscriptionID] = struct{}{}
+
+	return e.withBalance(valid(ev, tr.Amount), to)
+}
+
+// tickLengthValid 4 字节 tick 始终有效，5 字节 tick 在 self_mint 激活后有效
+func (e *Engine) tickLengthValid(height uint, tick string) bool {
+	switch len(tick) {
+	case tickLength:
+		return true
+	case selfMintTickLength:
+		return height >= e.opts.selfMintHeight
+	}
+	return false
+}
+
+func (e *Engine) balance(tick, address string) *Balance {
+	key := TickKey(tick)
+	m, ok := e.balances[key]
+	if !ok {
+		m = make(map[string]*Balance)
+		e.balances[key] = m
+	}
+	b, ok := m[address]
+	if !ok {
+		b = &Balance{}
+		m[address] = b
+	}
+	return b
+}
+
+func (e *Engine) withBalance(r Result, address string) Result {
+	b := e.balance(r.Tick, address)
+	r.Available = b.Available.String()
+	r.Transferable = b.Transferable.String()
+	r.Balance = b.Total().String()
+	return r
+}
+
+func valid(ev Event, amt decimal.Amount) Result {
+	ev.Content = ""
+	return Result{Event: ev, Valid: constant.BRC20_VALID_VALID, Amount: amt.String()}
+}
+
+func invalid(ev Event, status int, reason string) Result {
+	ev.Content = ""
+	return Result{Event: ev, Valid: status, Reason: reason}
+}
@@ -0,0 +1,68 @@
+package brc20
+
+import (
+	"encoding/json"
+	"web/constant"
+)
+
+// Event 区块内按顺序处理的 BRC-20 铭文事件
+// Op 取值为 constant.BRC20_OP_*，其中 send 表示 transfer 铭文被转移
+type Event struct {
+	Op                string `json:"op"`
+	Tick              string `json:"tick"`
+	InscriptionID     string `json:"inscription_id"`
+	InscriptionNumber int64  `json:"inscription_number"`
+	From              string `json:"from"`
+	To                string `json:"to"`
+
+	Amt string `json:"amt"`
+	Max string `json:"max"`
+	Lim string `json:"lim"`
+	Dec string `json:"dec"`
+
+	// 5 字节 tick 的 deploy 必须为 true
+	SelfMint bool `json:"self_mint,omitempty"`
+	// 父铭文 ID，self_mint 代币的 mint 必须以 deploy 铭文为父铭文
+	Parent string `json:"parent,omitempty"`
+
+	// 铭刻事件的原始铭文内容，不为空时由 ParseInscription 解析并覆盖 Op、Tick、Amt 等字段
+	// 结果中不保留
+	Content string `json:"content,omitempty"`
+}
+
+// Result 单个事件的处理结果，Valid 取值为 constant.BRC20_VALID_*
+type Result struct {
+	Event
+
+	Valid  int    `json:"valid"`
+	Reason string `json:"reason,omitempty"`
+	// 铭文内容解析失败时为 ParseError 的错误码（ErrCode*）
+	Code string `json:"code,omitempty"`
+
+	// 实际生效的数量（mint 超出 max 时会被截断）
+	Amount string `json:"amount"`
+	// 事件处理后 To（transfer 铭刻时为 From）的余额
+	Available    string `json:"available"`
+	Transferable string `json:"transferable"`
+	Balance      string `json:"balance"`
+}
+
+// Leaf 返回用于构建 Merkle 树的记录，字段顺序固定
+func (r Result) Leaf() string {
+	b, _ := json.Marshal(r)
+	return string(b)
+}
+
+// Leaves 按 BuildMerkleRequest 的格式拆分结果：铭刻事件进入 ins，send 进入 trx
+func Leaves(results []Result) (ins, trx []string) {
+	ins = make([]string, 0, len(results))
+	trx = make([]string, 0)
+	for _, r := range results {
+		if r.Op == constant.BRC20_OP_SEND {
+			trx = append(trx, r.Leaf())
+			continue
+		}
+		ins = append(ins, r.Leaf())
+	}
+	return ins, trx
+}
@@ -0,0 +1,63 @@
+package brc20
+
+import (
+	"web/config"
+	"web/constant"
+)
+
+type Options struct {
+	// 第一个 BRC-20 区块，之前的事件全部无效
+	firstHeight uint
+	// jubilee 高度，之前的 cursed（编号为负）铭文标记为 BRC20_VALID_CURSED，之后按普通铭文处理
+	jubileeHeight uint
+	// 5 字节 tick（self_mint）激活高度
+	selfMintHeight uint
+}
+
+type Option func(o *Options)
+
+func newOptions(options ...Option) Options {
+	opts := Options{
+		firstHeight:    constant.FIRST_BRC20_Block,
+		jubileeHeight:  constant.FirstJubilee,
+		selfMintHeight: constant.SelfMintBlock,
+	}
+
+	for _, o := range options {
+		o(&opts)
+	}
+	return opts
+}
+
+func WithFirstHeight(h uint) Option {
+	return func(o *Options) {
+		o.firstHeight = h
+	}
+}
+
+func WithJubileeHeight(h uint) Option {
+	return func(o *Options) {
+		o.jubileeHeight = h
+	}
+}
+
+func WithSelfMintHeight(h uint) Option {
+	return func(o *Options) {
+		o.selfMintHeight = h
+	}
+}
+
+// ConfigOptions 从配置生成选项，未配置（为 0）的项使用主网默认值
+func ConfigOptions(cfg config.Brc20) []Option {
+	var opts []Option
+	if cfg.FirstHeight > 0 {
+		opts = append(opts, WithFirstHeight(cfg.FirstHeight))
+	}
+	if cfg.JubileeHeight > 0 {
+		opts = append(opts, WithJubileeHeight(cfg.JubileeHeight))
+	}
+	if cfg.SelfMintHeight > 0 {
+		opts = append(opts, WithSelfMintHeight(cfg.SelfMintHeight))
+	}
+	return opts
+}
@@ -0,0 +1,244 @@
+package brc20
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strconv"
+	"web/constant"
+	"web/utils/decimal"
+)
+
+/*
+	parser 严格解析 BRC-20 铭文内容
+	1. 顶层必须是 JSON 对象，不允许重复的 key
+	2. p、op、tick、amt、max、lim、dec、self_mint 必须是字符串
+	3. p 必须为 constant.BRC20_P
+	4. tick 为 4 字节；5 字节 tick 只能用于 self_mint 代币，deploy 时必须带 "self_mint":"true"
+	5. tick 按 Unicode 完整的小写映射转换（TickKey），长度按原始 UTF-8 字节计算
+*/
+
+// 解析失败的错误码，值保持稳定，可直接用于对比报告
+const (
+	ErrCodeJSON           = "json_invalid"
+	ErrCodeNotObject      = "json_not_object"
+	ErrCodeDuplicateKey   = "duplicate_key"
+	ErrCodeNotString      = "field_not_string"
+	ErrCodeProtocol       = "protocol_mismatch"
+	ErrCodeOp             = "op_unknown"
+	ErrCodeTickMissing    = "tick_missing"
+	ErrCodeTickLength     = "tick_length"
+	ErrCodeSelfMint       = "self_mint_invalid"
+	ErrCodeAmtMissing     = "amt_missing"
+	ErrCodeAmtFormat      = "amt_format"
+	ErrCodeMaxMissing     = "max_missing"
+	ErrCodeMaxFormat      = "max_format"
+	ErrCodeLimFormat      = "lim_format"
+	ErrCodeDecFormat      = "dec_format"
+	ErrCodeUnexpectedData = "unexpected_data"
+)
+
+const (
+	selfMintTickLength = 5
+	selfMintTrue       = "true"
+)
+
+// 必须为字符串的字段
+var stringFields = []string{"p", "op", "tick", "amt", "max", "lim", "dec", "self_mint"}
+
+var opCodes = map[string]int{
+	constant.BRC20_OP_DEPLOY:   constant.BRC20_OP_N_DEPLOY,
+	constant.BRC20_OP_MINT:     constant.BRC20_OP_N_MINT,
+	constant.BRC20_OP_TRANSFER: constant.BRC20_OP_N_TRANSFER,
+}
+
+// ParseError 解析失败原因，Field 为出错的字段（可能为空）
+type ParseError struct {
+	Code  string
+	Field string
+}
+
+func (e *ParseError) Error() string {
+	if e.Field == "" {
+		return "brc20: " + e.Code
+	}
+	return "brc20: " + e.Code + ": " + e.Field
+}
+
+func parseErr(code, field string) error {
+	return &ParseError{Code: code, Field: field}
+}
+
+// Inscription 解析后的 BRC-20 铭文内容
+type Inscription struct {
+	Op     string
+	OpCode int
+	// Tick 为原始值，TickLower 为转小写后的值
+	Tick      string
+	TickLower string
+	Amt       string
+	Max       string
+	Lim       string
+	Dec       string
+	SelfMint  bool
+}
+
+// ParseInscription 解析铭文内容，失败时返回 *ParseError
+func ParseInscription(content []byte) (Inscription, error) {
+	var ret Inscription
+
+	fields, err := decodeObject(content)
+	if err != nil {
+		return ret, err
+	}
+
+	str := make(map[string]string, len(stringFields))
+	for _, k := range stringFields {
+		raw, ok := fields[k]
+		if !ok {
+			continue
+		}
+		var s string
+		if err = json.Unmarshal(raw, &s); err != nil {
+			return ret, parseErr(ErrCodeNotString, k)
+		}
+		str[k] = s
+	}
+
+	if str["p"] != constant.BRC20_P {
+		return ret, parseErr(ErrCodeProtocol, "p")
+	}
+
+	op, ok := opCodes[str["op"]]
+	if !ok {
+		return ret, parseErr(ErrCodeOp, "op")
+	}
+	ret.Op = str["op"]
+	ret.OpCode = op
+
+	ret.Tick, ok = str["tick"]
+	if !ok || ret.Tick == "" {
+		return ret, parseErr(ErrCodeTickMissing, "tick")
+	}
+	ret.TickLower = TickKey(ret.Tick)
+
+	if v, ok := str["self_mint"]; ok {
+		if v != selfMintTrue {
+			return ret, parseErr(ErrCodeSelfMint, "self_mint")
+		}
+		ret.SelfMint = true
+	}
+	switch len(ret.Tick) {
+	case tickLength:
+	case selfMintTickLength:
+		if op == constant.BRC20_OP_N_DEPLOY && !ret.SelfMint {
+			return ret, parseErr(ErrCodeSelfMint, "self_mint")
+		}
+	default:
+		return ret, parseErr(ErrCodeTickLength, "tick")
+	}
+
+	ret.Amt, ret.Max, ret.Lim, ret.Dec = str["amt"], str["max"], str["lim"], str["dec"]
+	if op == constant.BRC20_OP_N_DEPLOY {
+		return ret, checkDeploy(ret)
+	}
+
+	if _, ok = str["amt"]; !ok {
+		return ret, parseErr(ErrCodeAmtMissing, "amt")
+	}
+	if !isAmount(ret.Amt) {
+		return ret, parseErr(ErrCodeAmtFormat, "amt")
+	}
+	return ret, nil
+}
+
+func checkDeploy(ins Inscription) error {
+	if ins.Max == "" {
+		return parseErr(ErrCodeMaxMissing, "max")
+	}
+	// self_mint 代币 max 可以为 0，表示不限量
+	if !isAmount(ins.Max) && !(ins.SelfMint && ins.Max == "0") {
+		return parseErr(ErrCodeMaxFormat, "max")
+	}
+	if ins.Lim != "" && !isAmount(ins.Lim) {
+		return parseErr(ErrCodeLimFormat, "lim")
+	}
+	if ins.Dec != "" {
+		if d, err := strconv.ParseUint(ins.Dec, 10, 8); err != nil || d > maxDec {
+			return parseErr(ErrCodeDecFormat, "dec")
+		}
+	}
+	return nil
+}
+
+// Event 把铭文内容转换为 Engine 事件，铭文 ID、编号、地址等由调用方填写
+func (i Inscription) Event() Event {
+	return Event{
+		Op:       i.Op,
+		Tick:     i.Tick,
+		Amt:      i.Amt,
+		Max:      i.Max,
+		Lim:      i.Lim,
+		Dec:      i.Dec,
+		SelfMint: i.SelfMint,
+	}
+}
+
+// parseContent 按铭文内容填写事件，铭文 ID、编号、地址等保持不变
+func parseContent(ev Event) (Event, error) {
+	ins, err := ParseInscription([]byte(ev.Content))
+	if err != nil {
+		return ev, err
+	}
+	ret := ins.Event()
+	ret.InscriptionID = ev.InscriptionID
+	ret.InscriptionNumber = ev.InscriptionNumber
+	ret.From = ev.From
+	ret.To = ev.To
+	ret.Parent = ev.Parent
+	return ret, nil
+}
+
+// isAmount 只检查格式（按最大精度），精度与范围由 Engine 按代币检查
+func isAmount(s string) bool {
+	_, err := decimal.NewAmount(s, decimal.MaxDecimals)
+	return err == nil
+}
+
+// decodeObject 解析顶层 JSON 对象，重复的 key 返回错误
+func decodeObject(content []byte) (map[string]json.RawMessage, error) {
+	dec := json.NewDecoder(bytes.NewReader(content))
+
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, parseErr(ErrCodeJSON, "")
+	}
+	if d, ok := tok.(json.Delim); !ok || d != '{' {
+		return nil, parseErr(ErrCodeNotObject, "")
+	}
+
+	fields := make(map[string]json.RawMessage)
+	for dec.More() {
+		tok, err = dec.Token()
+		if err != nil {
+			return nil, parseErr(ErrCodeJSON, "")
+		}
+		key := tok.(string)
+		if _, ok := fields[key]; ok {
+			return nil, parseErr(ErrCodeDuplicateKey, key)
+		}
+
+		var raw json.RawMessage
+		if err = dec.Decode(&raw); err != nil {
+			return nil, parseErr(ErrCodeJSON, key)
+		}
+		fields[key] = raw
+	}
+	if _, err = dec.Token(); err != nil {
+		return nil, parseErr(ErrCodeJSON, "")
+	}
+	if _, err = dec.Token(); err != io.EOF {
+		return nil, parseErr(ErrCodeUnexpectedData, "")
+	}
+	return fields, nil
+}
@@ -0,0 +1,42 @@
+package brc20
+
+import (
+	"web/utils/decimal"
+)
+
+// Token 已部署的代币
+type Token struct {
+	Tick          string
+	Max           decimal.Amount
+	Lim           decimal.Amount
+	Dec           uint8
+	Minted        decimal.Amount
+	InscriptionID string
+	DeployHeight  uint
+	// 5 字节 tick，只能由 deploy 铭文的子铭文 mint
+	SelfMint bool
+}
+
+// Balance 地址在某个代币上的余额
+type Balance struct {
+	Available    decimal.Amount
+	Transferable decimal.Amount
+}
+
+// Total 可用余额与可转账余额之和
+func (b *Balance) Total() decimal.Amount {
+	// 同一地址的余额之和不超过代币的 max，不会溢出
+	t, err := b.Available.Add(b.Transferable)
+	if err != nil {
+		return b.Available
+	}
+	return t
+}
+
+// Transferable transfer 铭文，被转移（send）后失效
+type Transferable struct {
+	InscriptionID string
+	Tick          string
+	Owner         string
+	Amount        decimal.Amount
+}
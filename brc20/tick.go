@@ -0,0 +1,74 @@
+package brc20
+
+import (
+	"strings"
+	"unicode"
+)
+
+/*
+	tick 不区分大小写，比较键按 Unicode 完整的小写映射生成，与参考索引器（Python str.lower()）一致
+	strings.ToLower 只做逐字符的简单映射，例如 İ（U+0130）会变成 i，5 字节的 İabc 与 4 字节的 iabc 冲突
+	1. SpecialCasing 中无条件的映射：U+0130 → i + U+0307
+	2. Final_Sigma：词尾的 Σ 转为 ς，其他位置转为 σ
+	3. 其他字符使用 unicode.ToLower
+*/
+
+const (
+	capitalDottedI = 'İ'
+	capitalSigma   = 'Σ'
+	smallSigma     = 'σ'
+	finalSigma     = 'ς'
+)
+
+// TickKey 返回 tick 的小写比较键，parser、Engine 与数据库使用同一个键
+func TickKey(tick string) string {
+	rs := []rune(tick)
+	var b strings.Builder
+	b.Grow(len(tick) + 1)
+	for i, r := range rs {
+		switch r {
+		case capitalDottedI:
+			b.WriteString("i̇")
+		case capitalSigma:
+			if isFinalSigma(rs, i) {
+				b.WriteRune(finalSigma)
+			} else {
+				b.WriteRune(smallSigma)
+			}
+		default:
+			b.WriteRune(unicode.ToLower(r))
+		}
+	}
+	return b.String()
+}
+
+// isFinalSigma 前面（跳过 case-ignorable 字符）是 cased 字符，后面不是
+func isFinalSigma(rs []rune, i int) bool {
+	j := i - 1
+	for j >= 0 && caseIgnorable(rs[j]) {
+		j--
+	}
+	if j < 0 || !cased(rs[j]) {
+		return false
+	}
+	j = i + 1
+	for j < len(rs) && caseIgnorable(rs[j]) {
+		j++
+	}
+	return j == len(rs) || !cased(rs[j])
+}
+
+func cased(r rune) bool {
+	return unicode.IsUpper(r) || unicode.IsLower(r) || unicode.IsTitle(r) ||
+		unicode.In(r, unicode.Other_Lowercase, unicode.Other_Uppercase)
+}
+
+// caseIgnorable Unicode 的 Case_Ignorable：Mn、Me、Cf、Lm、Sk 以及 Word_Break 为 MidLetter、MidNumLet、Single_Quote 的字符
+func caseIgnorable(r rune) bool {
+	switch r {
+	case '\'', '.', ':', '·', '·', '՟', '״', '‘', '’',
+		'․', '‧', '︓', '﹒', '﹕', '＇', '．', '：':
+		return true
+	}
+	return unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf, unicode.Lm, unicode.Sk)
+}
@@ -0,0 +1,191 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"web/common"
+	"web/web/api"
+)
+
+/*
+	client 按 web/api 中的接口声明调用 validator
+	1. GET 请求按 form 标签编码为 query，其他请求编码为 JSON body
+	2. 解开 {data, code, msg, request_id} 外层，code 不为 SUCCESS 时返回 *common.Error
+*/
+
+//go:generate go run ./gen
+
+const defaultTimeout = 30 * time.Second
+
+type Client struct {
+	baseURL string
+	http    *http.Client
+	token   string
+}
+
+type Option func(c *Client)
+
+// WithHTTPClient 使用自定义的 http.Client
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.http = hc
+	}
+}
+
+// WithToken 请求带上 Authorization: Bearer <token>，用于管理接口
+func WithToken(token string) Option {
+	return func(c *Client) {
+		c.token = token
+	}
+}
+
+// New baseURL 为 validator 的地址，例如 http://127.0.0.1:8081
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		http:    &http.Client{Timeout: defaultTimeout},
+	}
+	for _, o := range opts {
+		o(c)
+	}
+	return c
+}
+
+type envelope struct {
+	Data      json.RawMessage `json:"data"`
+	Code      int             `json:"code"`
+	Msg       string          `json:"msg"`
+	RequestID string          `json:"request_id"`
+	Details   json.RawMessage `json:"details"`
+}
+
+// Call 调用 route 声明的接口
+func Call[T any, R any](ctx context.Context, c *Client, route api.Route[T, R], req *T) (R, error) {
+	var ret R
+
+	httpReq, err := c.newRequest(ctx, route.Info, req)
+	if err != nil {
+		return ret, err
+	}
+	resp, err := c.http.Do(httpReq)
+	if err != nil {
+		return ret, err
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return ret, err
+	}
+	var env envelope
+	if err = json.Unmarshal(b, &env); err != nil {
+		return ret, fmt.Errorf("%s %s: unexpected response %d: %w", route.Method, route.Path, resp.StatusCode, err)
+	}
+	if env.Code != common.SUCCESS {
+		return ret, responseErr(env)
+	}
+	if len(env.Data) > 0 {
+		err = json.Unmarshal(env.Data, &ret)
+	}
+	return ret, err
+}
+
+func (c *Client) newRequest(ctx context.Context, route api.Info, req any) (*http.Request, error) {
+	u := c.baseURL + route.Path
+	var body io.Reader
+	if route.Method == http.MethodGet {
+		if q := encodeQuery(req).Encode(); q != "" {
+			u += "?" + q
+		}
+	} else {
+		b, err := json.Marshal(req)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewReader(b)
+	}
+
+	r, err := http.NewRequestWithContext(ctx, route.Method, u, body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		r.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		r.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	return r, nil
+}
+
+// responseErr 把响应中的 code 还原为 *common.Error，参数错误时解析 details
+func responseErr(env envelope) error {
+	e := &common.Error{Code: env.Code, Msg: env.Msg}
+	if len(env.Details) == 0 || string(env.Details) == "null" {
+		return e
+	}
+	if env.Code == common.ParamsErr {
+		var fes common.FieldErrors
+		if json.Unmarshal(env.Details, &fes) == nil {
+			e.Details = fes
+			return e
+		}
+	}
+	e.Details = env.Details
+	return e
+}
+
+// encodeQuery 按 form 标签把结构体编码为 query，零值照常编码（例如 block=0），只跳过 nil 指针
+func encodeQuery(req any) url.Values {
+	q := url.Values{}
+	v := reflect.Indirect(reflect.ValueOf(req))
+	if !v.IsValid() || v.Kind() != reflect.Struct {
+		return q
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("form"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		f := v.Field(i)
+		if f.Kind() == reflect.Pointer {
+			if f.IsNil() {
+				continue
+			}
+			f = f.Elem()
+		}
+		if f.Kind() == reflect.Slice {
+			for j := 0; j < f.Len(); j++ {
+				q.Add(name, formatValue(f.Index(j)))
+			}
+			continue
+		}
+		q.Set(name, formatValue(f))
+	}
+	return q
+}
+
+func formatValue(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10)
+	}
+	return fmt.Sprint(v.Interface())
+}
@@ -0,0 +1,148 @@
+package client_test
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"web/client"
+	"web/common"
+	"web/config"
+	"web/logger"
+	"web/utils/merkle"
+	"web/web/handler"
+	"web/web/models"
+	"web/web/router"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+func init() {
+	logger.ErrorLogger = zap.NewNop().Sugar()
+	gin.SetMode(gin.TestMode)
+}
+
+func TestClient(t *testing.T) {
+	srv := httptest.NewServer(router.InitRouter())
+	defer srv.Close()
+	c := client.New(srv.URL)
+	ctx := context.Background()
+
+	ping, err := c.Ping(ctx)
+	if err != nil || ping.Ping != "pong" {
+		t.Fatalf("unexpected ping %+v (%v)", ping, err)
+	}
+
+	a, b := merkle.LeafHash([]byte("a")), merkle.LeafHash([]byte("b"))
+	tree := merkle.New([][]byte{a, b})
+	ret, err := c.VerifyMerkleProof(ctx, &models.VerifyMerkleProofRequest{
+		Data: "a",
+		Root: tree.RootHex(),
+		Path: []models.MerkleProof{{Hash: hex.EncodeToString(b), Position: "right"}},
+	})
+	if err != nil || !ret.Valid {
+		t.Fatalf("unexpected verify result %+v (%v)", ret, err)
+	}
+
+	// 内部节点加上半段路径不能当作叶子通过校验
+	cc, d := merkle.LeafHash([]byte("c")), merkle.LeafHash([]byte("d"))
+	four := merkle.New([][]byte{a, b, cc, d})
+	ret, err = c.VerifyMerkleProof(ctx, &models.VerifyMerkleProofRequest{
+		Data: string(merkle.NodeHash(a, b)),
+		Root: four.RootHex(),
+		Path: []models.MerkleProof{{Hash: hex.EncodeToString(merkle.NodeHash(cc, d)), Position: "right"}},
+	})
+	if err != nil || ret.Valid {
+		t.Fatalf("inner node passed as data should not verify %+v (%v)", ret, err)
+	}
+
+	// code 还原为 common.Error
+	_, err = c.VerifyMerkleProof(ctx, &models.VerifyMerkleProofRequest{Data: "a", Root: "zz"})
+	var ce *common.Error
+	if !errors.As(err, &ce) || ce.Code != common.ParamsErr {
+		t.Fatalf("expected ParamsErr, got %v", err)
+	}
+	if fes, ok := ce.Details.(common.FieldErrors); !ok || len(fes) != 1 || fes[0].Field != "root" {
+		t.Fatalf("unexpected details %#v", ce.Details)
+	}
+
+	// 0 是合法的区块高度
+	if diff, err := c.GetDiff(ctx, &models.WebDiffReq{Block: 0}); err != nil || diff.LocalExist {
+		t.Fatalf("unexpected diff for block 0 %+v (%v)", diff, err)
+	}
+
+	// GET 参数按 form 标签编码
+	_, err = c.GetList(ctx, &models.WebListReq{Limit: 2000})
+	if !errors.As(err, &ce) || ce.Code != common.ParamsErr {
+		t.Fatalf("expected ParamsErr, got %v", err)
+	}
+	if fes := ce.Details.(common.FieldErrors); fes[0].Field != "limit" {
+		t.Fatalf("unexpected details %+v", fes)
+	}
+}
+
+func TestAdminAuth(t *testing.T) {
+	defer func() { config.Configure.AdminSetting = config.Admin{} }()
+	ctx := context.Background()
+	var ce *common.Error
+
+	// 默认关闭，不注册管理接口
+	srv := httptest.NewServer(router.InitRouter())
+	if _, err := client.New(srv.URL).ListJobs(ctx); err == nil || errors.As(err, &ce) {
+		t.Fatalf("admin api should not be registered, got %v", err)
+	}
+	srv.Close()
+
+	config.Configure.AdminSetting = config.Admin{Enabled: true, Token: "secret"}
+	srv = httptest.NewServer(router.InitRouter())
+	defer srv.Close()
+
+	for _, c := range []*client.Client{client.New(srv.URL), client.New(srv.URL, client.WithToken("wrong"))} {
+		_, err := c.PauseJob(ctx, &models.PauseJobReq{Name: "checker", Paused: true})
+		if !errors.As(err, &ce) || ce.Code != common.Unauthorized {
+			t.Fatalf("expected Unauthorized, got %v", err)
+		}
+	}
+
+	// 没有启动任务调度
+	_, err := client.New(srv.URL, client.WithToken("secret")).ListJobs(ctx)
+	if !errors.As(err, &ce) || ce.Code != common.JobDisable {
+		t.Fatalf("expected JobDisable, got %v", err)
+	}
+
+	// 可重试的错误在响应中标记 retryable
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/api/admin/jobs", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var body handler.Response
+	if err = json.NewDecoder(resp.Body).Decode(&body); err != nil || !body.Retryable {
+		t.Fatalf("expected retryable response %+v (%v)", body, err)
+	}
+}
+
+func TestQueryZeroValues(t *testing.T) {
+	var query string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		_, _ = w.Write([]byte(`{"data":{},"code":200,"msg":"Success"}`))
+	}))
+	defer srv.Close()
+
+	_, err := client.New(srv.URL).GetList(context.Background(), &models.WebListReq{From: 0, To: 10, Status: []int{0, 2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	q, _ := url.ParseQuery(query)
+	if q.Get("from") != "0" || q.Get("to") != "10" || len(q["status"]) != 2 || q["status"][0] != "0" {
+		t.Fatalf("unexpected query %s", query)
+	}
+}
@@ -0,0 +1,43 @@
+// gen 根据 web/api 中的接口声明生成 client/routes.go
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"os"
+	"web/web/api"
+)
+
+func main() {
+	out := "routes.go"
+	if len(os.Args) > 1 {
+		out = os.Args[1]
+	}
+
+	var b bytes.Buffer
+	b.WriteString("// Code generated by client/gen; DO NOT EDIT.\n\npackage client\n\n")
+	b.WriteString("import (\n\t\"context\"\n\t\"web/web/api\"\n\t\"web/web/models\"\n)\n")
+
+	for _, r := range api.Routes() {
+		if r.Deprecated {
+			continue
+		}
+		fmt.Fprintf(&b, "\n// %s %s %s\n", r.Name, r.Method, r.Path)
+		if r.Request.NumField() == 0 {
+			fmt.Fprintf(&b, "func (c *Client) %s(ctx context.Context) (models.%s, error) {\n", r.Name, r.Response.Name())
+			fmt.Fprintf(&b, "\treturn Call(ctx, c, api.%s, &models.%s{})\n}\n", r.Name, r.Request.Name())
+			continue
+		}
+		fmt.Fprintf(&b, "func (c *Client) %s(ctx context.Context, req *models.%s) (models.%s, error) {\n", r.Name, r.Request.Name(), r.Response.Name())
+		fmt.Fprintf(&b, "\treturn Call(ctx, c, api.%s, req)\n}\n", r.Name)
+	}
+
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		panic(err)
+	}
+	if err = os.WriteFile(out, src, 0o644); err != nil {
+		panic(err)
+	}
+}
@@ -1,5 +1,10 @@
 package common
 
+import (
+	"fmt"
+	"net/http"
+)
+
 const (
 	SUCCESS       = 200
 	ERROR         = 500
@@ -7,40 +12,79 @@ const (
 	Unknown       = 99999
 	FileNotExist  = 10001
 	ParamsErr     = 10002
+	FileSaveErr   = 10003
+	FileReadErr   = 10004
+	LeafNotExist  = 10005
+	RemoteDisable = 10006
+	RemoteErr     = 10007
+	DBErr         = 10008
+	ChainReorg    = 10009
+	JobNotExist   = 10010
+	JobRunning    = 10011
+	JobDisable    = 10012
+	JobNotLeader  = 10013
+	Unauthorized  = 10014
 )
 
-var MsgFlags = map[int]string{
-	Unknown:      "Unknown",
-	SUCCESS:      "Success",
-	FileNotExist: "File not exist",
-	ParamsErr:    "ParamsErr",
+// Spec 错误码的定义
+type Spec struct {
+	Code int
+	Msg  string
+	// 返回给客户端的 HTTP 状态码
+	Status int
+	// 客户端稍后重试是否可能成功
+	Retryable bool
 }
 
-// GetMsg get error information based on Code
-func GetMsg(code int) string {
-	msg, ok := MsgFlags[code]
-	if ok {
-		return msg
-	}
+var registry = map[int]Spec{}
 
-	return MsgFlags[ERROR]
+func init() {
+	for _, s := range []Spec{
+		{Code: SUCCESS, Msg: "Success", Status: http.StatusOK},
+		{Code: ERROR, Msg: "Internal error", Status: http.StatusInternalServerError},
+		{Code: InvalidParams, Msg: "Invalid params", Status: http.StatusBadRequest},
+		{Code: Unknown, Msg: "Unknown", Status: http.StatusInternalServerError},
+		{Code: FileNotExist, Msg: "File not exist", Status: http.StatusNotFound},
+		{Code: ParamsErr, Msg: "ParamsErr", Status: http.StatusBadRequest},
+		{Code: FileSaveErr, Msg: "File save failed", Status: http.StatusInternalServerError, Retryable: true},
+		{Code: FileReadErr, Msg: "File read failed", Status: http.StatusInternalServerError, Retryable: true},
+		{Code: LeafNotExist, Msg: "Leaf not exist", Status: http.StatusNotFound},
+		{Code: RemoteDisable, Msg: "Remote source not configured", Status: http.StatusNotImplemented},
+		{Code: RemoteErr, Msg: "Remote fetch failed", Status: http.StatusBadGateway, Retryable: true},
+		{Code: DBErr, Msg: "Database error", Status: http.StatusInternalServerError, Retryable: true},
+		{Code: ChainReorg, Msg: "Chain reorg, push again from local_last_push + 1", Status: http.StatusConflict, Retryable: true},
+		{Code: JobNotExist, Msg: "Job not exist", Status: http.StatusNotFound},
+		{Code: JobRunning, Msg: "Job is running", Status: http.StatusConflict, Retryable: true},
+		{Code: JobDisable, Msg: "Job scheduler not running", Status: http.StatusServiceUnavailable, Retryable: true},
+		{Code: JobNotLeader, Msg: "Job only runs on the leader instance", Status: http.StatusConflict},
+		{Code: Unauthorized, Msg: "Unauthorized", Status: http.StatusUnauthorized},
+	} {
+		Register(s)
+	}
 }
 
-type Error struct {
-	err  string
-	Code int
+// Register 注册错误码，重复注册会 panic
+func Register(s Spec) {
+	if _, ok := registry[s.Code]; ok {
+		panic(fmt.Sprintf("error code %d already registered", s.Code))
+	}
+	if s.Status == 0 {
+		s.Status = http.StatusInternalServerError
+	}
+	registry[s.Code] = s
 }
 
-func (m *Error) Error() string {
-	return m.err
+// Lookup 返回错误码的定义，未注册的错误码按 Unknown 处理
+func Lookup(code int) Spec {
+	if s, ok := registry[code]; ok {
+		return s
+	}
+	s := registry[Unknown]
+	s.Code = code
+	return s
 }
 
-func New(code int) error {
-	if code == SUCCESS {
-		return nil
-	}
-	return &Error{
-		err:  GetMsg(code),
-		Code: code,
-	}
+// GetMsg get error information based on Code
+func GetMsg(code int) string {
+	return Lookup(code).Msg
 }
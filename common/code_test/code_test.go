@@ -0,0 +1,40 @@
+package code_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"web/common"
+)
+
+func TestWrap(t *testing.T) {
+	cause := errors.New("disk full")
+	err := common.Wrap(common.FileSaveErr, cause)
+
+	e := common.FromError(fmt.Errorf("build: %w", err))
+	if e.Code != common.FileSaveErr || e.Msg != "File save failed" {
+		t.Fatalf("unexpected error %+v", e)
+	}
+	if e.Status() != http.StatusInternalServerError || !e.Retryable() {
+		t.Fatalf("unexpected spec status=%d retryable=%v", e.Status(), e.Retryable())
+	}
+	if !errors.Is(err, cause) || err.Error() != "File save failed: disk full" {
+		t.Fatalf("cause should be kept for logs: %v", err)
+	}
+}
+
+func TestLookup(t *testing.T) {
+	if common.GetMsg(123456) != common.GetMsg(common.Unknown) {
+		t.Fatal("unregistered code should fall back to Unknown")
+	}
+	if common.Lookup(common.FileNotExist).Status != http.StatusNotFound {
+		t.Fatal("unexpected status")
+	}
+	if e := common.FromError(errors.New("boom")); e.Code != common.Unknown || e.Msg != "Unknown" {
+		t.Fatalf("plain error should map to Unknown %+v", e)
+	}
+	if common.New(common.SUCCESS) != nil {
+		t.Fatal("success is not an error")
+	}
+}
@@ -1,5 +1,100 @@
 package common
 
-var (
-	ErrorFileNotExist = Error{Code: 10001, err: "file not exist"}
+import (
+	"errors"
+	"strings"
 )
+
+// Error 接口错误，Msg 与 Details 返回给客户端，cause 只用于日志
+type Error struct {
+	Code    int
+	Msg     string
+	Details any
+
+	cause error
+}
+
+// Error 包含原始错误，用于日志
+func (m *Error) Error() string {
+	if m.cause == nil {
+		return m.Msg
+	}
+	return m.Msg + ": " + m.cause.Error()
+}
+
+func (m *Error) Unwrap() error {
+	return m.cause
+}
+
+// Status 返回错误码对应的 HTTP 状态码
+func (m *Error) Status() int {
+	return Lookup(m.Code).Status
+}
+
+// Retryable 客户端稍后重试是否可能成功
+func (m *Error) Retryable() bool {
+	return Lookup(m.Code).Retryable
+}
+
+// WithDetails 返回附带详细信息的副本
+func (m *Error) WithDetails(details any) *Error {
+	e := *m
+	e.Details = details
+	return &e
+}
+
+func New(code int) error {
+	if code == SUCCESS {
+		return nil
+	}
+	return &Error{
+		Code: code,
+		Msg:  GetMsg(code),
+	}
+}
+
+// Wrap 生成错误码对应的错误并保留原始错误，原始错误不会返回给客户端
+func Wrap(code int, cause error) error {
+	if code == SUCCESS {
+		return nil
+	}
+	return &Error{
+		Code:  code,
+		Msg:   GetMsg(code),
+		cause: cause,
+	}
+}
+
+// FromError 从错误链中取出 *Error，没有时按 Unknown 处理并保留原始错误
+func FromError(err error) *Error {
+	if err == nil {
+		return nil
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	return Wrap(Unknown, err).(*Error)
+}
+
+// FieldError 单个参数的校验错误，作为 ParamsErr 的 details 返回
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+func (e FieldError) Error() string {
+	return e.Message
+}
+
+// FieldErrors 多个参数的校验错误
+type FieldErrors []FieldError
+
+func (e FieldErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, fe := range e {
+		msgs = append(msgs, fe.Message)
+	}
+	return strings.Join(msgs, "; ")
+}
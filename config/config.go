@@ -17,10 +17,19 @@ type Configuration struct {
 	ServerSetting  Server    `json:"server"`
 	MerkleSetting  Merkle    `json:"merkle"`
 	RuntimeSetting Runtime   `json:"runtime"`
+	Brc20Setting   Brc20     `json:"brc20"`
+	BlockSource    Source    `json:"block_source"`
+	// 按任务名覆盖默认的调度设置
+	JobSetting    map[string]Job `json:"jobs"`
+	LeaderSetting Leader         `json:"leader"`
+	Snowflake     Snowflake      `json:"snowflake"`
+	AdminSetting  Admin          `json:"admin"`
 }
 
 type Postgre struct {
-	Conf map[string]string `json:"conf"`
+	// 数据库驱动，默认 postgres，可选值见 database/opens.go
+	Driver string            `json:"driver"`
+	Conf   map[string]string `json:"conf"`
 }
 
 type Server struct {
@@ -29,6 +38,8 @@ type Server struct {
 	ReadTimeout     time.Duration `json:"read_timeout"`
 	WriteTimeout    time.Duration `json:"write_timeout"`
 	ShutDownTimeout time.Duration `json:"shut_down_timeout"`
+	// 单个请求的截止时间（秒），0 表示不限制
+	RequestTimeout time.Duration `json:"request_timeout"`
 }
 
 type App struct {
@@ -42,10 +53,74 @@ type App struct {
 
 type Merkle struct {
 	RemoteSource string `json:"remote_source"`
-	RemotePath   string `json:"remote_path"`
-	FileSource   string `json:"file_source"`
-	FilePath     string `json:"file_path"`
-	FileExt      string `json:"file_ext"`
+	// 远端数据的本地镜像，配置 remote_source 时必填
+	RemotePath string `json:"remote_path"`
+	// 为空时默认重试 3 次，0 表示不重试
+	RemoteRetry   *int          `json:"remote_retry"`
+	RemoteBackoff time.Duration `json:"remote_backoff"`
+	FileSource    string        `json:"file_source"`
+	FilePath      string        `json:"file_path"`
+	FileExt       string        `json:"file_ext"`
+}
+
+// Brc20 激活高度，为 0 时使用主网默认值（constant.FIRST_BRC20_Block、constant.FirstJubilee、constant.SelfMintBlock）
+type Brc20 struct {
+	FirstHeight    uint `json:"first_height"`
+	JubileeHeight  uint `json:"jubilee_height"`
+	SelfMintHeight uint `json:"self_mint_height"`
+}
+
+// Source 区块数据来源，type 为空时只接收 PUT /api/merkle/build 推送
+type Source struct {
+	// rpc 或 replay
+	Type        string `json:"type"`
+	RpcUrl      string `json:"rpc_url"`
+	RpcUser     string `json:"rpc_user"`
+	RpcPassword string `json:"rpc_password"`
+	// ord 服务地址，rpc 来源从它的 JSON API 读取铭文
+	OrdUrl string `json:"ord_url"`
+	// replay 读取的 JSONL 文件或目录
+	ReplayPath string `json:"replay_path"`
+	// 拉取间隔与 rpc 超时，单位秒
+	Interval time.Duration `json:"interval"`
+	Timeout  time.Duration `json:"timeout"`
+}
+
+// Job 任务调度设置，cron 不为空时忽略 interval，时间单位为秒
+type Job struct {
+	Interval time.Duration `json:"interval"`
+	Cron     string        `json:"cron"`
+	Jitter   time.Duration `json:"jitter"`
+	Timeout  time.Duration `json:"timeout"`
+	Paused   bool          `json:"paused"`
+}
+
+// Leader 多实例部署时的 leader 选举，type 为空时不选举，所有任务都在本实例运行
+type Leader struct {
+	// pg 或 file
+	Type string `json:"type"`
+	// 竞争同一把锁的实例使用相同的 name，默认 odin-validator
+	Name string `json:"name"`
+	// file 锁的路径，默认 <runtime_path>/leader.lock
+	Path string `json:"path"`
+	// 检查锁的间隔（秒）
+	Interval time.Duration `json:"interval"`
+}
+
+// Snowflake 雪花算法的机器 ID，取值均为 0 - 31
+// worker_id 为空时从数据库租用一个未被占用的 worker ID，没有数据库时使用 0
+type Snowflake struct {
+	WorkerID     *int64 `json:"worker_id"`
+	DataCenterID int64  `json:"datacenter_id"`
+	// 租约有效期（秒），默认 30，每 1/3 有效期续约一次
+	LeaseTTL time.Duration `json:"lease_ttl"`
+}
+
+// Admin 管理接口 /api/admin/*，默认关闭
+// 开启时必须配置 token，请求需带上 Authorization: Bearer <token>
+type Admin struct {
+	Enabled bool   `json:"enabled"`
+	Token   string `json:"token"`
 }
 
 type Runtime struct {
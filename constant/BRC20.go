@@ -3,6 +3,8 @@ package constant
 const (
 	FIRST_BRC20_Block = 779832
 	FirstJubilee      = 824544
+	// 5 字节 tick（self_mint）激活高度
+	SelfMintBlock = 837090
 )
 
 // brc20 protocal
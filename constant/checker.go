@@ -0,0 +1,12 @@
+package constant
+
+// checker 区块对账状态
+const (
+	CHECK_STATUS_DEFAULT        = 0
+	CHECK_STATUS_MATCHED        = 1
+	CHECK_STATUS_MISMATCHED     = 2
+	CHECK_STATUS_MISSING_LOCAL  = 3
+	CHECK_STATUS_MISSING_REMOTE = 4
+	// 两侧都没有，更高的区块已经存在
+	CHECK_STATUS_MISSING_BOTH = 5
+)
@@ -17,6 +17,7 @@ const (
 	RequestTime = "request_time"
 )
 
+// Deprecated: 后台任务使用 Background 创建 context
 func GetGinContextWithRequestId() *gin.Context {
 	ctx := gin.Context{}
 
@@ -80,8 +81,11 @@ func GetRequestId() (requestId string) {
 }
 
 func AddRequestId(ctx *gin.Context) {
-	requestId := GetRequestId()
-	SetRequestID(ctx, requestId)
+	requestId := GetRequestID(ctx)
+	if requestId == "" {
+		requestId = GetRequestId()
+		SetRequestID(ctx, requestId)
+	}
 	body, err := ctx.GetRawData()
 	if err != nil {
 		logger.Error(err.Error())
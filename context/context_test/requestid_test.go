@@ -0,0 +1,68 @@
+package context_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"web/context"
+	"web/logger"
+	"web/web/handler"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+func init() {
+	logger.ErrorLogger = zap.NewNop().Sugar()
+	gin.SetMode(gin.TestMode)
+}
+
+type req struct{}
+
+func serve(t *testing.T, header map[string]string) (string, string, string) {
+	var fromCtx string
+	r := gin.New()
+	r.Use(context.RequestIDMiddleware())
+	r.GET("/test", handler.TRPathParamHandler(func(c *gin.Context, t *req) (string, error) {
+		// 传给 GORM 的 context.Context 中也能取到
+		fromCtx = context.RequestIDFrom(c.Request.Context())
+		return "ok", nil
+	}))
+
+	w := httptest.NewRecorder()
+	hr := httptest.NewRequest(http.MethodGet, "/test", nil)
+	for k, v := range header {
+		hr.Header.Set(k, v)
+	}
+	r.ServeHTTP(w, hr)
+
+	var resp handler.Response
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	return w.Header().Get(context.HeaderRequestID), resp.RequestID, fromCtx
+}
+
+func TestRequestID(t *testing.T) {
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	cases := []struct {
+		header map[string]string
+		want   string
+	}{
+		{map[string]string{context.HeaderRequestID: "abc"}, "abc"},
+		{map[string]string{context.HeaderLegacyRequestID: "legacy"}, "legacy"},
+		{map[string]string{context.HeaderTraceparent: "00-" + traceID + "-00f067aa0ba902b7-01"}, traceID},
+		{map[string]string{context.HeaderTraceparent: "00-00000000000000000000000000000000-00f067aa0ba902b7-01"}, ""},
+		{nil, ""},
+	}
+	for _, c := range cases {
+		header, body, ctx := serve(t, c.header)
+		if header == "" || header != body || header != ctx {
+			t.Fatalf("request id should be the same everywhere: header=%q body=%q ctx=%q", header, body, ctx)
+		}
+		if c.want != "" && header != c.want {
+			t.Fatalf("want %q got %q", c.want, header)
+		}
+	}
+}
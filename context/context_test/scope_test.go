@@ -0,0 +1,101 @@
+package context_test
+
+import (
+	stdctx "context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"web/context"
+	dlog "web/db_logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequestContext(t *testing.T) {
+	var (
+		id          string
+		hasDeadline bool
+		md          context.MD
+	)
+	r := gin.New()
+	r.ContextWithFallback = true
+	r.Use(context.RequestIDMiddleware())
+	r.Use(context.RequestContext(time.Minute))
+	r.GET("/test", func(c *gin.Context) {
+		// db_logger 的元数据与请求级 context 共用
+		dlog.UseMetadata(c)
+		dlog.AddNotice(c, "k", "v")
+
+		// *gin.Context 直接当作请求级 context 使用
+		id = context.RequestIDFrom(c.Request.Context())
+		_, hasDeadline = c.Deadline()
+		md, _ = context.MetadataFrom(c)
+		if context.Logger(c) == nil {
+			t.Error("logger missing")
+		}
+	})
+
+	w := httptest.NewRecorder()
+	hr := httptest.NewRequest(http.MethodGet, "/test", nil)
+	hr.Header.Set(context.HeaderRequestID, "abc")
+	r.ServeHTTP(w, hr)
+
+	if id != "abc" {
+		t.Fatalf("request id %q", id)
+	}
+	if !hasDeadline {
+		t.Fatal("deadline not set")
+	}
+	notice, _ := md[dlog.Notice].(map[string]interface{})
+	if notice["k"] != "v" {
+		t.Fatalf("metadata %v", md)
+	}
+}
+
+func TestRequestContextCancel(t *testing.T) {
+	parent, cancel := stdctx.WithCancel(stdctx.Background())
+	var err error
+	r := gin.New()
+	r.ContextWithFallback = true
+	r.Use(context.RequestContext(0))
+	r.GET("/test", func(c *gin.Context) {
+		// 客户端断开或服务关闭
+		cancel()
+		<-c.Done()
+		err = c.Err()
+	})
+
+	hr := httptest.NewRequest(http.MethodGet, "/test", nil).WithContext(parent)
+	r.ServeHTTP(httptest.NewRecorder(), hr)
+	if err != stdctx.Canceled {
+		t.Fatalf("err %v", err)
+	}
+}
+
+func TestBackground(t *testing.T) {
+	parent, cancel := stdctx.WithCancel(stdctx.Background())
+	a := context.Background(parent, "checker")
+	b := context.Background(parent, "checker")
+
+	if context.RequestIDFrom(a) == "" || context.RequestIDFrom(a) == context.RequestIDFrom(b) {
+		t.Fatalf("request id %q %q", context.RequestIDFrom(a), context.RequestIDFrom(b))
+	}
+	if md, _ := context.MetadataFrom(a); md["job"] != "checker" {
+		t.Fatalf("metadata %v", md)
+	}
+	if context.Logger(a) == nil {
+		t.Fatal("logger missing")
+	}
+
+	cancel()
+	if a.Err() == nil {
+		t.Fatal("not canceled with parent")
+	}
+
+	// 异步任务保留元数据与请求 ID，但不随原 context 取消
+	async := dlog.WithContext(a)
+	if async.Err() != nil || context.RequestIDFrom(async) != context.RequestIDFrom(a) {
+		t.Fatal("async context")
+	}
+}
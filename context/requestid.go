@@ -0,0 +1,79 @@
+package context
+
+import (
+	stdctx "context"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+/*
+	请求 ID 的唯一来源
+	1. 优先使用请求头 X-Request-Id（兼容旧的 X_Safeis_RequestId），其次使用 W3C traceparent 中的 trace-id，都没有时生成
+	2. 写入 gin keys、request 的 context.Context（GORM 通过 WithContext 读取）以及响应头
+*/
+
+const (
+	HeaderRequestID       = "X-Request-Id"
+	HeaderLegacyRequestID = "X_Safeis_RequestId"
+	HeaderTraceparent     = "traceparent"
+
+	// 请求头中的 ID 超过该长度时忽略
+	maxRequestIDLen = 128
+)
+
+type requestIDKey struct{}
+
+// traceparent: version-traceid-parentid-flags
+var traceparentRe = regexp.MustCompile(`^[0-9a-f]{2}-([0-9a-f]{32})-[0-9a-f]{16}-[0-9a-f]{2}$`)
+
+// RequestIDMiddleware 为每个请求确定唯一的请求 ID，需要放在其他中间件之前
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := incomingRequestID(c)
+		if id == "" {
+			id = GetRequestId()
+		}
+
+		SetRequestID(c, id)
+		c.Request = c.Request.WithContext(WithRequestID(c.Request.Context(), id))
+		c.Writer.Header().Set(HeaderRequestID, id)
+		c.Next()
+	}
+}
+
+func incomingRequestID(c *gin.Context) string {
+	for _, h := range []string{HeaderRequestID, HeaderLegacyRequestID} {
+		if id := strings.TrimSpace(c.GetHeader(h)); id != "" && len(id) <= maxRequestIDLen {
+			return id
+		}
+	}
+	return TraceID(c.GetHeader(HeaderTraceparent))
+}
+
+// TraceID 返回 traceparent 中的 trace-id，格式不合法或全为 0 时返回空
+func TraceID(traceparent string) string {
+	m := traceparentRe.FindStringSubmatch(strings.TrimSpace(traceparent))
+	if m == nil || strings.Trim(m[1], "0") == "" {
+		return ""
+	}
+	return m[1]
+}
+
+// WithRequestID 把请求 ID 放入 context.Context
+func WithRequestID(ctx stdctx.Context, id string) stdctx.Context {
+	return stdctx.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFrom 从 context.Context 中读取请求 ID，*gin.Context 从 keys 中读取
+func RequestIDFrom(ctx stdctx.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	if c, ok := ctx.(*gin.Context); ok {
+		return GetRequestID(c)
+	}
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
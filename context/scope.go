@@ -0,0 +1,116 @@
+package context
+
+import (
+	stdctx "context"
+	"time"
+	"web/logger"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+/*
+	请求级 context.Context，handler、任务、DAO（db.WithContext）与日志共用同一个
+	1. 携带请求 ID、截止时间、元数据 MD 以及带请求 ID 的日志对象
+	2. HTTP 请求由 RequestContext 挂到 c.Request 上，客户端断开或服务关闭时取消，进行中的 SQL 随之取消
+	3. router 开启 ContextWithFallback，*gin.Context 可以直接当作该 context 使用
+	4. 后台任务使用 Background 从主 context 派生，不再伪造 gin.Context
+*/
+
+// MD 请求级元数据
+type MD map[string]any
+
+type (
+	mdKey     struct{}
+	loggerKey struct{}
+)
+
+// Len returns the number of items in md.
+func (md MD) Len() int {
+	return len(md)
+}
+
+// Copy returns a copy of md.
+func (md MD) Copy() MD {
+	out := make(MD, len(md))
+	for k, v := range md {
+		out[k] = v
+	}
+	return out
+}
+
+// New 返回携带请求 ID、空元数据与日志对象的 context，id 为空时生成
+func New(parent stdctx.Context, id string) stdctx.Context {
+	if id == "" {
+		id = GetRequestId()
+	}
+	ctx := WithRequestID(parent, id)
+	if _, ok := MetadataFrom(ctx); !ok {
+		ctx = WithMetadata(ctx, MD{})
+	}
+	return WithLogger(ctx, baseLogger().With("request_id", id))
+}
+
+// Background 为后台任务的一次执行创建 context，随 parent 一起取消
+func Background(parent stdctx.Context, name string) stdctx.Context {
+	id := GetRequestId()
+	ctx := WithRequestID(parent, id)
+	ctx = WithMetadata(ctx, MD{"job": name})
+	return WithLogger(ctx, baseLogger().With("request_id", id, "job", name))
+}
+
+// RequestContext 把请求级 context 挂到 c.Request 上，需要放在 RequestIDMiddleware 之后
+// timeout 大于 0 时作为请求的截止时间
+func RequestContext(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := New(c.Request.Context(), GetRequestID(c))
+		if timeout > 0 {
+			var cancel stdctx.CancelFunc
+			ctx, cancel = stdctx.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// WithMetadata 把元数据放入 context.Context
+func WithMetadata(ctx stdctx.Context, md MD) stdctx.Context {
+	return stdctx.WithValue(ctx, mdKey{}, md)
+}
+
+// MetadataFrom 从 context.Context 中读取元数据，返回的 MD 与 context 共享
+func MetadataFrom(ctx stdctx.Context) (MD, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	md, ok := ctx.Value(mdKey{}).(MD)
+	return md, ok
+}
+
+// WithLogger 把日志对象放入 context.Context
+func WithLogger(ctx stdctx.Context, l *zap.SugaredLogger) stdctx.Context {
+	return stdctx.WithValue(ctx, loggerKey{}, l)
+}
+
+// Logger 返回 context 中的日志对象，没有时返回带请求 ID 的全局日志对象
+func Logger(ctx stdctx.Context) *zap.SugaredLogger {
+	if ctx == nil {
+		return baseLogger()
+	}
+	if l, ok := ctx.Value(loggerKey{}).(*zap.SugaredLogger); ok && l != nil {
+		return l
+	}
+	if id := RequestIDFrom(ctx); id != "" {
+		return baseLogger().With("request_id", id)
+	}
+	return baseLogger()
+}
+
+func baseLogger() *zap.SugaredLogger {
+	if logger.ErrorLogger == nil {
+		return zap.NewNop().Sugar()
+	}
+	// logger 包的函数多包了一层，直接调用时去掉这一层
+	return logger.ErrorLogger.WithOptions(zap.AddCallerSkip(-1))
+}
@@ -0,0 +1,100 @@
+package dao
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 每个区块的对账结果，按 number 唯一
+// 更新方式：insert & update
+type BlockCheck struct {
+	ID         uint      `gorm:"column:id;primaryKey"`
+	CreatedAt  time.Time `gorm:"column:created_at"`
+	UpdatedAt  time.Time `gorm:"column:updated_at"`
+	Number     uint      `gorm:"column:number;uniqueIndex"`
+	Hash       string    `gorm:"column:hash"`
+	LocalRoot  string    `gorm:"column:local_root"`
+	RemoteRoot string    `gorm:"column:remote_root"`
+	Status     int       `gorm:"column:status;index"`
+}
+
+const blockCheckTableName = "block_check"
+
+func (c *BlockCheck) TableName() string {
+	return blockCheckTableName
+}
+
+// SaveBlockCheck 按区块号写入或覆盖对账结果
+func SaveBlockCheck(db *gorm.DB, check BlockCheck) error {
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "number"}},
+		DoUpdates: clause.AssignmentColumns([]string{"updated_at", "hash", "local_root", "remote_root", "status"}),
+	}).Create(&check).Error
+}
+
+// DeleteBlockChecksFrom 删除区块号大于等于 number 的对账结果
+func DeleteBlockChecksFrom(db *gorm.DB, number uint) error {
+	return db.Where("number >= ?", number).Delete(&BlockCheck{}).Error
+}
+
+// ListLatestBlockChecks 按区块号倒序返回最近的对账结果
+func ListLatestBlockChecks(db *gorm.DB, limit int) ([]BlockCheck, error) {
+	var ret []BlockCheck
+	err := db.Model(BlockCheck{}).Order("number desc").Limit(limit).Find(&ret).Error
+	return ret, err
+}
+
+// ListBlockChecksByStatus 按区块号升序返回 number > after 且为指定状态的对账结果
+func ListBlockChecksByStatus(db *gorm.DB, status []int, after uint, limit int) ([]BlockCheck, error) {
+	var ret []BlockCheck
+	err := db.Model(BlockCheck{}).Where("status IN ? AND number > ?", status, after).Order("number asc").Limit(limit).Find(&ret).Error
+	return ret, err
+}
+
+// BlockCheckFilter 对账结果查询条件，零值表示不过滤
+type BlockCheckFilter struct {
+	Status []int
+	// 区块号范围，闭区间
+	From uint
+	To   uint
+	// 只返回该时间之后更新的结果
+	Since time.Time
+	// 游标分页：升序返回 number > Cursor，降序返回 number < Cursor
+	Cursor uint
+	Desc   bool
+	Limit  int
+}
+
+// ListBlockChecks 按条件分页查询对账结果
+func ListBlockChecks(db *gorm.DB, filter BlockCheckFilter) ([]BlockCheck, error) {
+	tx := db.Model(BlockCheck{})
+	if len(filter.Status) > 0 {
+		tx = tx.Where("status IN ?", filter.Status)
+	}
+	if filter.From > 0 {
+		tx = tx.Where("number >= ?", filter.From)
+	}
+	if filter.To > 0 {
+		tx = tx.Where("number <= ?", filter.To)
+	}
+	if !filter.Since.IsZero() {
+		tx = tx.Where("updated_at >= ?", filter.Since)
+	}
+	if filter.Desc {
+		if filter.Cursor > 0 {
+			tx = tx.Where("number < ?", filter.Cursor)
+		}
+		tx = tx.Order("number desc")
+	} else {
+		if filter.Cursor > 0 {
+			tx = tx.Where("number > ?", filter.Cursor)
+		}
+		tx = tx.Order("number asc")
+	}
+
+	var ret []BlockCheck
+	err := tx.Limit(filter.Limit).Find(&ret).Error
+	return ret, err
+}
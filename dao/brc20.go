@@ -0,0 +1,360 @@
+package dao
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+	"web/brc20"
+	"web/utils/decimal"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 已部署的代币，tick 为小写
+// 数量字段统一按十进制字符串保存，避免不同数据库的 numeric 精度差异
+// 更新方式：insert & update
+type Brc20Token struct {
+	Tick          string `gorm:"column:tick;primaryKey"`
+	TickRaw       string `gorm:"column:tick_raw"`
+	Max           string `gorm:"column:max;type:varchar(80)"`
+	Lim           string `gorm:"column:lim;type:varchar(80)"`
+	Dec           uint8  `gorm:"column:dec"`
+	Minted        string `gorm:"column:minted;type:varchar(80)"`
+	InscriptionID string `gorm:"column:inscription_id"`
+	DeployHeight  uint   `gorm:"column:deploy_height"`
+	SelfMint      bool   `gorm:"column:self_mint"`
+}
+
+func (c *Brc20Token) TableName() string {
+	return "brc20_token"
+}
+
+// 地址余额，tick 为小写
+// 更新方式：insert & update
+type Brc20Balance struct {
+	Tick         string `gorm:"column:tick;primaryKey"`
+	Address      string `gorm:"column:address;primaryKey"`
+	Available    string `gorm:"column:available;type:varchar(80)"`
+	Transferable string `gorm:"column:transferable;type:varchar(80)"`
+}
+
+func (c *Brc20Balance) TableName() string {
+	return "brc20_balance"
+}
+
+// 尚未被 send 的 transfer 铭文
+// 更新方式：insert & delete
+type Brc20Transferable struct {
+	InscriptionID string `gorm:"column:inscription_id;primaryKey"`
+	Tick          string `gorm:"column:tick;index"`
+	Owner         string `gorm:"column:owner;index"`
+	Amount        string `gorm:"column:amount;type:varchar(80)"`
+}
+
+func (c *Brc20Transferable) TableName() string {
+	return "brc20_transferable"
+}
+
+// 每个区块内按顺序处理的事件及结果
+// 更新方式：insert，回滚时删除
+type Brc20Event struct {
+	ID                uint   `gorm:"column:id;primaryKey"`
+	Height            uint   `gorm:"column:height;index"`
+	Idx               int    `gorm:"column:idx"`
+	Op                string `gorm:"column:op"`
+	Tick              string `gorm:"column:tick;index"`
+	InscriptionID     string `gorm:"column:inscription_id"`
+	InscriptionNumber int64  `gorm:"column:inscription_number"`
+	From              string `gorm:"column:from_address"`
+	To                string `gorm:"column:to_address"`
+	Amount            string `gorm:"column:amount;type:varchar(80)"`
+	Valid             int    `gorm:"column:valid"`
+	Reason            string `gorm:"column:reason"`
+	Leaf              string `gorm:"column:leaf"`
+}
+
+func (c *Brc20Event) TableName() string {
+	return "brc20_event"
+}
+
+// 已处理的区块
+// 更新方式：insert，回滚时删除
+type Brc20Block struct {
+	Height    uint      `gorm:"column:height;primaryKey;autoIncrement:false"`
+	CreatedAt time.Time `gorm:"column:created_at"`
+	Events    int       `gorm:"column:events"`
+}
+
+func (c *Brc20Block) TableName() string {
+	return "brc20_block"
+}
+
+const (
+	undoKindToken        = "token"
+	undoKindBalance      = "balance"
+	undoKindTransferable = "transferable"
+)
+
+// 区块修改前的状态，用于回滚；Before 为空表示修改前不存在该行
+// 更新方式：insert，回滚时删除
+type Brc20Undo struct {
+	ID            uint   `gorm:"column:id;primaryKey"`
+	Height        uint   `gorm:"column:height;index"`
+	Kind          string `gorm:"column:kind"`
+	Tick          string `gorm:"column:tick"`
+	Address       string `gorm:"column:address"`
+	InscriptionID string `gorm:"column:inscription_id"`
+	Before        string `gorm:"column:before_image"`
+}
+
+func (c *Brc20Undo) TableName() string {
+	return "brc20_undo"
+}
+
+// LastBrc20Block 返回最后处理的区块高度，没有记录时返回 0
+func LastBrc20Block(db *gorm.DB) (uint, error) {
+	var height uint
+	err := db.Model(Brc20Block{}).Select("COALESCE(MAX(height), 0)").Scan(&height).Error
+	return height, err
+}
+
+// SaveBrc20Block 在一个事务内写入区块的事件、状态修改和回滚记录
+func SaveBrc20Block(db *gorm.DB, height uint, results []brc20.Result, changes brc20.Changes) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, t := range changes.Tokens {
+			row := tokenRow(t)
+			if err := saveUndo(tx, height, undoKindToken, &Brc20Token{}, "tick = ?", []any{row.Tick},
+				Brc20Undo{Tick: row.Tick}); err != nil {
+				return err
+			}
+			if err := tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(&row).Error; err != nil {
+				return err
+			}
+		}
+		for _, b := range changes.Balances {
+			row := Brc20Balance{
+				Tick:         b.Tick,
+				Address:      b.Address,
+				Available:    b.Available.String(),
+				Transferable: b.Transferable.String(),
+			}
+			if err := saveUndo(tx, height, undoKindBalance, &Brc20Balance{}, "tick = ? AND address = ?", []any{row.Tick, row.Address},
+				Brc20Undo{Tick: row.Tick, Address: row.Address}); err != nil {
+				return err
+			}
+			if err := tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(&row).Error; err != nil {
+				return err
+			}
+		}
+		for _, t := range changes.Transferables {
+			row := transferableRow(t)
+			if err := saveUndo(tx, height, undoKindTransferable, &Brc20Transferable{}, "inscription_id = ?", []any{row.InscriptionID},
+				Brc20Undo{InscriptionID: row.InscriptionID}); err != nil {
+				return err
+			}
+			if err := tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(&row).Error; err != nil {
+				return err
+			}
+		}
+		for _, id := range changes.Removed {
+			if err := saveUndo(tx, height, undoKindTransferable, &Brc20Transferable{}, "inscription_id = ?", []any{id},
+				Brc20Undo{InscriptionID: id}); err != nil {
+				return err
+			}
+			if err := tx.Where("inscription_id = ?", id).Delete(&Brc20Transferable{}).Error; err != nil {
+				return err
+			}
+		}
+
+		if len(results) > 0 {
+			events := make([]Brc20Event, 0, len(results))
+			for i, r := range results {
+				events = append(events, Brc20Event{
+					Height:            height,
+					Idx:               i,
+					Op:                r.Op,
+					Tick:              r.Tick,
+					InscriptionID:     r.InscriptionID,
+					InscriptionNumber: r.InscriptionNumber,
+					From:              r.From,
+					To:                r.To,
+					Amount:            r.Amount,
+					Valid:             r.Valid,
+					Reason:            r.Reason,
+					Leaf:              r.Leaf(),
+				})
+			}
+			if err := tx.CreateInBatches(events, 100).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Create(&Brc20Block{Height: height, Events: len(results)}).Error
+	})
+}
+
+// saveUndo 读取修改前的行并写入回滚记录
+func saveUndo(tx *gorm.DB, height uint, kind string, before any, query string, args []any, undo Brc20Undo) error {
+	ret := tx.Where(query, args...).Limit(1).Find(before)
+	if ret.Error != nil {
+		return ret.Error
+	}
+	if ret.RowsAffected > 0 {
+		b, err := json.Marshal(before)
+		if err != nil {
+			return err
+		}
+		undo.Before = string(b)
+	}
+	undo.Height = height
+	undo.Kind = kind
+	return tx.Create(&undo).Error
+}
+
+// RollbackToBlock 撤销高度大于 height 的区块对状态的修改，回滚后最后处理的区块为 height
+func RollbackToBlock(db *gorm.DB, height uint) error {
+	return RollbackFrom(db, height+1)
+}
+
+// RollbackFrom 撤销高度大于等于 height 的区块对状态的修改，height 为 0 时回滚全部区块
+func RollbackFrom(db *gorm.DB, height uint) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var undos []Brc20Undo
+		if err := tx.Where("height >= ?", height).Order("id desc").Find(&undos).Error; err != nil {
+			return err
+		}
+		for _, u := range undos {
+			if err := restoreUndo(tx, u); err != nil {
+				return err
+			}
+		}
+		for _, m := range []any{&Brc20Undo{}, &Brc20Event{}, &Brc20Block{}} {
+			if err := tx.Where("height >= ?", height).Delete(m).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func restoreUndo(tx *gorm.DB, u Brc20Undo) error {
+	var (
+		row   any
+		query string
+		args  []any
+	)
+	switch u.Kind {
+	case undoKindToken:
+		row, query, args = &Brc20Token{}, "tick = ?", []any{u.Tick}
+	case undoKindBalance:
+		row, query, args = &Brc20Balance{}, "tick = ? AND address = ?", []any{u.Tick, u.Address}
+	case undoKindTransferable:
+		row, query, args = &Brc20Transferable{}, "inscription_id = ?", []any{u.InscriptionID}
+	default:
+		return fmt.Errorf("unknown undo kind %q", u.Kind)
+	}
+
+	if u.Before == "" {
+		return tx.Where(query, args...).Delete(row).Error
+	}
+	if err := json.Unmarshal([]byte(u.Before), row); err != nil {
+		return err
+	}
+	return tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(row).Error
+}
+
+// LoadBrc20Engine 从数据库恢复引擎状态
+func LoadBrc20Engine(db *gorm.DB, opts ...brc20.Option) (*brc20.Engine, error) {
+	e := brc20.NewEngine(opts...)
+
+	var tokens []Brc20Token
+	if err := db.Find(&tokens).Error; err != nil {
+		return nil, err
+	}
+	decs := make(map[string]uint8, len(tokens))
+	for _, row := range tokens {
+		t, err := row.token()
+		if err != nil {
+			return nil, fmt.Errorf("token %s: %w", row.Tick, err)
+		}
+		decs[row.Tick] = row.Dec
+		e.RestoreToken(t)
+	}
+
+	var balances []Brc20Balance
+	if err := db.Find(&balances).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range balances {
+		dec := decs[row.Tick]
+		available, err := decimal.NewAmount(row.Available, dec)
+		if err != nil {
+			return nil, fmt.Errorf("balance %s %s: %w", row.Tick, row.Address, err)
+		}
+		transferable, err := decimal.NewAmount(row.Transferable, dec)
+		if err != nil {
+			return nil, fmt.Errorf("balance %s %s: %w", row.Tick, row.Address, err)
+		}
+		e.RestoreBalance(row.Tick, row.Address, brc20.Balance{Available: available, Transferable: transferable})
+	}
+
+	var transferables []Brc20Transferable
+	if err := db.Find(&transferables).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range transferables {
+		amt, err := decimal.NewAmount(row.Amount, decs[brc20.TickKey(row.Tick)])
+		if err != nil {
+			return nil, fmt.Errorf("transferable %s: %w", row.InscriptionID, err)
+		}
+		e.RestoreTransferable(brc20.Transferable{
+			InscriptionID: row.InscriptionID,
+			Tick:          row.Tick,
+			Owner:         row.Owner,
+			Amount:        amt,
+		})
+	}
+	return e, nil
+}
+
+func tokenRow(t brc20.Token) Brc20Token {
+	return Brc20Token{
+		Tick:          brc20.TickKey(t.Tick),
+		TickRaw:       t.Tick,
+		Max:           t.Max.String(),
+		Lim:           t.Lim.String(),
+		Dec:           t.Dec,
+		Minted:        t.Minted.String(),
+		InscriptionID: t.InscriptionID,
+		DeployHeight:  t.DeployHeight,
+		SelfMint:      t.SelfMint,
+	}
+}
+
+func (c Brc20Token) token() (brc20.Token, error) {
+	t := brc20.Token{
+		Tick:          c.TickRaw,
+		Dec:           c.Dec,
+		InscriptionID: c.InscriptionID,
+		DeployHeight:  c.DeployHeight,
+		SelfMint:      c.SelfMint,
+	}
+	var err error
+	if t.Max, err = decimal.NewAmount(c.Max, c.Dec); err != nil {
+		return t, err
+	}
+	if t.Lim, err = decimal.NewAmount(c.Lim, c.Dec); err != nil {
+		return t, err
+	}
+	t.Minted, err = decimal.NewAmount(c.Minted, c.Dec)
+	return t, err
+}
+
+func transferableRow(t brc20.Transferable) Brc20Transferable {
+	return Brc20Transferable{
+		InscriptionID: t.InscriptionID,
+		Tick:          t.Tick,
+		Owner:         t.Owner,
+		Amount:        t.Amount.String(),
+	}
+}
@@ -0,0 +1,53 @@
+package dao_test
+
+import (
+	"testing"
+	"web/constant"
+	"web/dao"
+	"web/database"
+
+	"gorm.io/gorm"
+)
+
+func newDB(t *testing.T) *gorm.DB {
+	db, err := database.NewDB(database.WithMaxOpenConns(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = dao.AutoMigrate(db); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestListBlockChecks(t *testing.T) {
+	db := newDB(t)
+	for n := uint(1); n <= 10; n++ {
+		status := constant.CHECK_STATUS_MATCHED
+		if n%3 == 0 {
+			status = constant.CHECK_STATUS_MISMATCHED
+		}
+		if err := dao.SaveBlockCheck(db, dao.BlockCheck{Number: n, Status: status}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 覆盖已有结果
+	_ = dao.SaveBlockCheck(db, dao.BlockCheck{Number: 1, Status: constant.CHECK_STATUS_MISMATCHED})
+
+	filter := dao.BlockCheckFilter{Status: []int{constant.CHECK_STATUS_MISMATCHED}, Desc: true, Limit: 2}
+	page, err := dao.ListBlockChecks(db, filter)
+	if err != nil || len(page) != 2 || page[0].Number != 9 || page[1].Number != 6 {
+		t.Fatalf("unexpected first page %+v (%v)", page, err)
+	}
+
+	filter.Cursor = page[1].Number
+	page, _ = dao.ListBlockChecks(db, filter)
+	if len(page) != 2 || page[0].Number != 3 || page[1].Number != 1 {
+		t.Fatalf("unexpected second page %+v", page)
+	}
+
+	page, _ = dao.ListBlockChecks(db, dao.BlockCheckFilter{From: 4, To: 6, Limit: 10})
+	if len(page) != 3 || page[0].Number != 4 {
+		t.Fatalf("unexpected range %+v", page)
+	}
+}
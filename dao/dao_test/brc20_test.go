@@ -0,0 +1,108 @@
+package dao_test
+
+import (
+	"testing"
+	"web/brc20"
+	"web/constant"
+	"web/dao"
+
+	"gorm.io/gorm"
+)
+
+const brc20Height = constant.FIRST_BRC20_Block
+
+func applyBlock(t *testing.T, db *gorm.DB, e *brc20.Engine, height uint, events []brc20.Event) {
+	rs := e.ApplyBlock(height, events)
+	if err := dao.SaveBrc20Block(db, height, rs, e.Flush()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBrc20Rollback(t *testing.T) {
+	db := newDB(t)
+	e := brc20.NewEngine()
+	applyBlock(t, db, e, brc20Height, []brc20.Event{
+		{Op: constant.BRC20_OP_DEPLOY, Tick: "Ordi", Max: "1000", Dec: "2"},
+		{Op: constant.BRC20_OP_MINT, Tick: "ordi", To: "a", Amt: "100.5"},
+	})
+	applyBlock(t, db, e, brc20Height+1, []brc20.Event{
+		{Op: constant.BRC20_OP_TRANSFER, Tick: "ordi", To: "a", Amt: "30", InscriptionID: "t1"},
+		{Op: constant.BRC20_OP_TRANSFER, Tick: "ordi", To: "a", Amt: "20", InscriptionID: "t2"},
+	})
+	applyBlock(t, db, e, brc20Height+2, []brc20.Event{
+		{Op: constant.BRC20_OP_SEND, InscriptionID: "t1", From: "a", To: "b"},
+		{Op: constant.BRC20_OP_MINT, Tick: "ordi", To: "c", Amt: "1"},
+	})
+
+	// 从数据库恢复的状态与内存中一致
+	loaded, err := dao.LoadBrc20Engine(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := loaded.Balance("ordi", "b"); b.Available.String() != "30" {
+		t.Fatalf("unexpected loaded balance %+v", b)
+	}
+	if tk, ok := loaded.Token("ordi"); !ok || tk.Minted.String() != "101.5" || tk.Tick != "Ordi" {
+		t.Fatalf("unexpected loaded token %+v", tk)
+	}
+
+	if err = dao.RollbackToBlock(db, brc20Height); err != nil {
+		t.Fatal(err)
+	}
+	if last, _ := dao.LastBrc20Block(db); last != brc20Height {
+		t.Fatalf("unexpected last block %d", last)
+	}
+	loaded, err = dao.LoadBrc20Engine(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := loaded.Balance("ordi", "a"); b.Available.String() != "100.5" || !b.Transferable.IsZero() {
+		t.Fatalf("unexpected balance after rollback %+v", b)
+	}
+	if b := loaded.Balance("ordi", "b"); !b.Total().IsZero() {
+		t.Fatalf("receiver balance should be removed %+v", b)
+	}
+	var count int64
+	db.Model(dao.Brc20Transferable{}).Count(&count)
+	if count != 0 {
+		t.Fatalf("transferables should be removed, got %d", count)
+	}
+
+	// 回滚后可以重新处理后续区块
+	applyBlock(t, db, loaded, brc20Height+1, []brc20.Event{
+		{Op: constant.BRC20_OP_TRANSFER, Tick: "ordi", To: "a", Amt: "100.5", InscriptionID: "t3"},
+	})
+	if last, _ := dao.LastBrc20Block(db); last != brc20Height+1 {
+		t.Fatalf("unexpected last block %d", last)
+	}
+}
+
+func TestBrc20RollbackAll(t *testing.T) {
+	db := newDB(t)
+	e := brc20.NewEngine(brc20.WithFirstHeight(0))
+	applyBlock(t, db, e, 0, []brc20.Event{
+		{Op: constant.BRC20_OP_DEPLOY, Tick: "ordi", Max: "1000"},
+		{Op: constant.BRC20_OP_MINT, Tick: "ordi", To: "a", Amt: "1"},
+	})
+
+	if _, ok := e.Token("ordi"); !ok {
+		t.Fatal("deploy at block 0 failed")
+	}
+
+	// 从区块 0 开始回滚时不保留任何状态
+	if err := dao.RollbackFrom(db, 0); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := dao.LoadBrc20Engine(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := loaded.Token("ordi"); ok {
+		t.Fatal("token deployed at block 0 should be removed")
+	}
+	var count int64
+	db.Model(dao.Brc20Block{}).Count(&count)
+	if count != 0 {
+		t.Fatalf("blocks should be removed, got %d", count)
+	}
+}
@@ -0,0 +1,52 @@
+package dao_test
+
+import (
+	"context"
+	"testing"
+	"time"
+	"web/dao"
+)
+
+func TestSnowflakeLease(t *testing.T) {
+	store := dao.SnowflakeLeaseStore{DB: newDB(t)}
+	ctx := context.Background()
+	expires := time.Now().Add(time.Minute)
+
+	a, ok, err := store.Acquire(ctx, 1, 1, "a", expires)
+	if err != nil || !ok || a != 0 {
+		t.Fatalf("acquire a: %d %v %v", a, ok, err)
+	}
+	b, ok, _ := store.Acquire(ctx, 1, 1, "b", expires)
+	if !ok || b != 1 {
+		t.Fatalf("acquire b: %d %v", b, ok)
+	}
+	// 全部被占用
+	if _, ok, _ = store.Acquire(ctx, 1, 1, "c", expires); ok {
+		t.Fatal("acquire c should fail")
+	}
+	// 其他 datacenter 互不影响
+	if id, ok, _ := store.Acquire(ctx, 2, 1, "c", expires); !ok || id != 0 {
+		t.Fatalf("acquire c in datacenter 2: %d %v", id, ok)
+	}
+
+	// a 过期后由 c 接手，a 不能再续约
+	if ok, err = store.Renew(ctx, 1, a, "a", time.Now().Add(-time.Second)); err != nil || !ok {
+		t.Fatalf("renew a: %v %v", ok, err)
+	}
+	if id, ok, _ := store.Acquire(ctx, 1, 1, "c", expires); !ok || id != a {
+		t.Fatalf("take over a: %d %v", id, ok)
+	}
+	if ok, _ = store.Renew(ctx, 1, a, "a", expires); ok {
+		t.Fatal("renew a after taken over")
+	}
+
+	// 只释放自己的租约
+	_ = store.Release(ctx, 1, a, "a")
+	if _, ok, _ = store.Acquire(ctx, 1, 1, "d", expires); ok {
+		t.Fatal("release by a should not free c's lease")
+	}
+	_ = store.Release(ctx, 1, b, "b")
+	if id, ok, _ := store.Acquire(ctx, 1, 1, "d", expires); !ok || id != b {
+		t.Fatalf("acquire after release: %d %v", id, ok)
+	}
+}
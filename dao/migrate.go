@@ -0,0 +1,17 @@
+package dao
+
+import "gorm.io/gorm"
+
+// AutoMigrate 创建或更新 dao 中定义的表
+func AutoMigrate(db *gorm.DB) error {
+	return db.AutoMigrate(
+		&BlockCheck{},
+		&Brc20Token{},
+		&Brc20Balance{},
+		&Brc20Transferable{},
+		&Brc20Event{},
+		&Brc20Block{},
+		&Brc20Undo{},
+		&SnowflakeLease{},
+	)
+}
@@ -0,0 +1,81 @@
+package dao
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 雪花算法 worker ID 的租约，同一 datacenter 下 worker_id 唯一
+// 更新方式：insert & update，实例关闭时 delete
+type SnowflakeLease struct {
+	DataCenterID int64     `gorm:"column:datacenter_id;primaryKey;autoIncrement:false"`
+	WorkerID     int64     `gorm:"column:worker_id;primaryKey;autoIncrement:false"`
+	Owner        string    `gorm:"column:owner"`
+	ExpiresAt    time.Time `gorm:"column:expires_at"`
+}
+
+func (c *SnowflakeLease) TableName() string {
+	return "snowflake_lease"
+}
+
+// SnowflakeLeaseStore 实现 snowflake.LeaseStore，时间统一按 UTC 保存
+type SnowflakeLeaseStore struct {
+	DB *gorm.DB
+}
+
+// Acquire 按 worker ID 从小到大，插入空闲的或者接手已过期的租约
+func (s SnowflakeLeaseStore) Acquire(ctx context.Context, dataCenterID, maxWorkerID int64, owner string, expiresAt time.Time) (int64, bool, error) {
+	db := s.DB.WithContext(ctx)
+
+	var leases []SnowflakeLease
+	if err := db.Where("datacenter_id = ?", dataCenterID).Find(&leases).Error; err != nil {
+		return 0, false, err
+	}
+	used := make(map[int64]bool, len(leases))
+	for _, l := range leases {
+		used[l.WorkerID] = true
+	}
+
+	now := time.Now().UTC()
+	for id := int64(0); id <= maxWorkerID; id++ {
+		var res *gorm.DB
+		if !used[id] {
+			res = db.Clauses(clause.OnConflict{DoNothing: true}).Create(&SnowflakeLease{
+				DataCenterID: dataCenterID,
+				WorkerID:     id,
+				Owner:        owner,
+				ExpiresAt:    expiresAt.UTC(),
+			})
+		} else {
+			// 条件更新，多个实例同时接手时只有一个成功
+			res = db.Model(&SnowflakeLease{}).
+				Where("datacenter_id = ? AND worker_id = ? AND (expires_at < ? OR owner = ?)", dataCenterID, id, now, owner).
+				Updates(map[string]any{"owner": owner, "expires_at": expiresAt.UTC()})
+		}
+		if res.Error != nil {
+			return 0, false, res.Error
+		}
+		if res.RowsAffected == 1 {
+			return id, true, nil
+		}
+	}
+	return 0, false, nil
+}
+
+// Renew 租约仍属于 owner 时延长到 expiresAt
+func (s SnowflakeLeaseStore) Renew(ctx context.Context, dataCenterID, workerID int64, owner string, expiresAt time.Time) (bool, error) {
+	res := s.DB.WithContext(ctx).Model(&SnowflakeLease{}).
+		Where("datacenter_id = ? AND worker_id = ? AND owner = ?", dataCenterID, workerID, owner).
+		Update("expires_at", expiresAt.UTC())
+	return res.RowsAffected == 1, res.Error
+}
+
+// Release 只删除属于 owner 的租约
+func (s SnowflakeLeaseStore) Release(ctx context.Context, dataCenterID, workerID int64, owner string) error {
+	return s.DB.WithContext(ctx).
+		Where("datacenter_id = ? AND worker_id = ? AND owner = ?", dataCenterID, workerID, owner).
+		Delete(&SnowflakeLease{}).Error
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"web/constant"
+	webctx "web/context"
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm/logger"
 	"gorm.io/gorm/utils"
@@ -23,12 +24,12 @@ type gormLogger struct {
 }
 
 func (l *gormLogger) getLogger(ctx context.Context, fileLine, sqlStr string, elapsed, rows int64) *logrus.Entry {
-	requestId := ctx.Value(constant.CONTEXT_KEY_REQUEST_ID)
+	requestId := webctx.RequestIDFrom(ctx)
 	entry := logrus.NewEntry(l.l)
 	fields := make(logrus.Fields, 6)
 	fields["subModule"] = "gorm"
 	fields["fileLine"] = fileLine
-	if requestId != nil {
+	if requestId != "" {
 		fields[constant.CONTEXT_KEY_REQUEST_ID] = requestId
 	}
 	if len(sqlStr) > 0 {
@@ -9,10 +9,11 @@ import (
 	"net/http"
 	"os"
 	"runtime"
-	"strconv"
 	"strings"
 	"time"
 
+	webctx "web/context"
+
 	"github.com/gin-gonic/gin"
 	"github.com/rifflock/lfshook"
 
@@ -24,14 +25,14 @@ import (
 
 const (
 	LogContextKey       = "log_entry"
-	ContextKeyRequestID = "requestId"
+	ContextKeyRequestID = webctx.RequestId
 	ContextKeyNoLog     = "_no_log"
 	SLoggerKey          = "slogger"
 )
 
 // header key
 const (
-	RequestIDHeaderKey = "X_Safeis_RequestId"
+	RequestIDHeaderKey = webctx.HeaderRequestID
 )
 
 const (
@@ -161,30 +162,17 @@ func AddFields(c *gin.Context, fieldsList ...logrus.Fields) *logrus.Entry {
 	return SLogger.LogEntryWithContext(c, fields)
 }
 
+// GetRequestID 返回 context.RequestIDMiddleware 确定的请求 ID，没有经过中间件时生成
 func GetRequestID(ctx *gin.Context) string {
 	if ctx == nil {
-		return genRequestId()
+		return webctx.GetRequestId()
 	}
-
-	// 从ctx中获取
-	if r := ctx.GetString(ContextKeyRequestID); r != "" {
+	if r := webctx.GetRequestID(ctx); r != "" {
 		return r
 	}
 
-	// 优先从header中获取
-	var requestId string
-	if ctx.Request != nil && ctx.Request.Header != nil {
-		requestId = ctx.Request.Header.Get(RequestIDHeaderKey)
-	}
-
-	// 新生成
-	if requestId == "" {
-		requestId = genRequestId()
-	}
-
-	ctx.Set(ContextKeyRequestID, requestId)
-	ctx.Request.Header.Set(RequestIDHeaderKey, requestId)
-	ctx.Writer.Header().Set(RequestIDHeaderKey, requestId)
+	requestId := webctx.GetRequestId()
+	webctx.SetRequestID(ctx, requestId)
 	return requestId
 }
 
@@ -491,13 +479,6 @@ func function(pc uintptr) []byte {
 	return name
 }
 
-func genRequestId() (requestId string) {
-	// 随机生成
-	usec := uint64(time.Now().UnixNano())
-	requestId = strconv.FormatUint(usec&0x7FFFFFFF|0x80000000, 10)
-	return requestId
-}
-
 // // access 添加kv打印
 // func AddNotice(k string, v interface{}) gin.HandlerFunc {
 // 	return func(c *gin.Context) {
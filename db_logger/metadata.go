@@ -5,12 +5,13 @@ import (
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"strconv"
+
+	webctx "web/context"
 )
 
 // MD is a mapping from metadata keys to values.
-type MD map[string]interface{}
-
-type mdKey struct{}
+// 与 web/context 共用同一个类型，请求级 context 中的元数据在这里同样可见
+type MD = webctx.MD
 
 const (
 	Notice   = "notice"
@@ -18,16 +19,6 @@ const (
 	_CTX_KEY = "safeis/metadata.ctx"
 )
 
-// Len returns the number of items in md.
-func (md MD) Len() int {
-	return len(md)
-}
-
-// Copy returns a copy of md.
-func (md MD) Copy() MD {
-	return Join(md)
-}
-
 // Join joins any number of mds into a single MD.
 // The order of values for each key is determined by the order in which
 // the mds containing those values are presented to Join.
@@ -70,32 +61,32 @@ func Pairs(kv ...interface{}) MD {
 
 // NewContext creates a new context with md attached.
 func NewContext(ctx context.Context, md MD) context.Context {
-	return context.WithValue(ctx, mdKey{}, md)
+	return webctx.WithMetadata(ctx, md)
 }
 
 // FromContext returns the incoming metadata in ctx if it exists.  The
 // returned MD should not be modified. Writing to it may cause races.
 // Modification should be made to copies of the returned MD.
 func FromContext(ctx context.Context) (md MD, ok bool) {
-	md, ok = ctx.Value(mdKey{}).(MD)
-	return
+	return webctx.MetadataFrom(ctx)
 }
 
-// WithContext return no deadline context and retain metadata.
+// WithContext return no deadline context and retain metadata, request id and logger.
 func WithContext(c context.Context) context.Context {
+	ctx := context.WithoutCancel(c)
 	md, ok := FromContext(c)
 	if ok {
 		nmd := md.Copy()
 		// NOTE: temporary delete prevent asynchronous task reuse finished task
 		delete(nmd, Trace)
-		return NewContext(context.Background(), nmd)
+		return NewContext(ctx, nmd)
 	}
-	return context.Background()
+	return ctx
 }
 
 // Value get value from metadata in context return nil if not found
 func Value(ctx context.Context, key string) interface{} {
-	md, ok := ctx.Value(mdKey{}).(MD)
+	md, ok := FromContext(ctx)
 	if !ok {
 		return nil
 	}
@@ -104,7 +95,7 @@ func Value(ctx context.Context, key string) interface{} {
 
 // String get string value from metadata in context
 func String(ctx context.Context, key string) string {
-	md, ok := ctx.Value(mdKey{}).(MD)
+	md, ok := FromContext(ctx)
 	if !ok {
 		return ""
 	}
@@ -114,7 +105,7 @@ func String(ctx context.Context, key string) string {
 
 // Int64 get int64 value from metadata in context
 func Int64(ctx context.Context, key string) int64 {
-	md, ok := ctx.Value(mdKey{}).(MD)
+	md, ok := FromContext(ctx)
 	if !ok {
 		return 0
 	}
@@ -124,7 +115,7 @@ func Int64(ctx context.Context, key string) int64 {
 
 // Bool get boolean from metadata in context use strconv.Parse.
 func Bool(ctx context.Context, key string) bool {
-	md, ok := ctx.Value(mdKey{}).(MD)
+	md, ok := FromContext(ctx)
 	if !ok {
 		return false
 	}
@@ -147,9 +138,20 @@ func Metadata() gin.HandlerFunc {
 	}
 }
 
+// UseMetadata 元数据放在请求级 context 中，经过 webctx.RequestContext 的请求直接复用
 func UseMetadata(ctx *gin.Context) {
-	if _, ok := CtxFromGinContext(ctx); !ok {
-		GinCtxWithCtx(ctx, NewContext4Gin())
+	meta, ok := CtxFromGinContext(ctx)
+	if !ok {
+		if ctx.Request == nil {
+			meta = NewContext(context.Background(), MD{})
+		} else {
+			meta = NewContext(ctx.Request.Context(), MD{})
+			ctx.Request = ctx.Request.WithContext(meta)
+		}
+		GinCtxWithCtx(ctx, meta)
+	}
+	if md, _ := FromContext(meta); md[Notice] == nil {
+		md[Notice] = make(map[string]interface{})
 	}
 }
 
@@ -159,6 +161,11 @@ func CtxFromGinContext(c *gin.Context) (context.Context, bool) {
 			res := v.(context.Context)
 			return res, true
 		}
+		if c.Request != nil {
+			if _, ok := FromContext(c.Request.Context()); ok {
+				return c.Request.Context(), true
+			}
+		}
 	}
 	return nil, false
 }
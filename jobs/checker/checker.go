@@ -2,29 +2,241 @@ package checker
 
 import (
 	"context"
+	"errors"
+	"sync"
 	"time"
 
-	"web/logger"
+	"web/constant"
+	"web/dao"
+	"web/repository/merklefetch"
+	"web/repository/merklestore"
+	"web/repository/pg"
+	"web/repository/state"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// 每次最多向前检查的区块数
+	walkBatch = 100
+	// 每次最多重新检查的缺失区块数
+	recheckBatch = 20
+	// 每次最多向远端探测的新区块数
+	probeBatch = 10
+	// 远端没有新区块时的探测间隔，连续没有时翻倍
+	probeBackoff    = 2 * time.Second
+	probeMaxBackoff = time.Minute
 )
 
-func CheckerJob(ctx context.Context) {
-	// init checker setting
-	logger.Info("CheckerJob running now.")
+// 远端探测的退避状态
+var probeState struct {
+	mu     sync.Mutex
+	misses int
+	next   time.Time
+}
+
+// Run 执行一轮检查，由 jobs 调度器定时调用
+func Run(ctx context.Context) error {
+	db, err := pg.GetDB(constant.DBNameMain)
+	if err != nil {
+		return err
+	}
+	db = db.WithContext(ctx)
+
+	// 先探测远端的新区块，walk 才能检查到远端领先的部分
+	// 各步互不依赖，一步失败时其他步骤照常执行
+	err = probe(ctx)
+	return errors.Join(err, recheck(ctx, db), walk(ctx, db))
+}
+
+// probe 从两侧已知的最高区块之后向远端探测新区块，拉取成功时由 OnFetch 更新 RemoteLastPush
+// 远端没有新区块或拉取失败时按 probeBackoff 退避
+func probe(ctx context.Context) error {
+	f := merklefetch.GetFetcher()
+	if f == nil {
+		return nil
+	}
+	probeState.mu.Lock()
+	defer probeState.mu.Unlock()
+	if time.Now().Before(probeState.next) {
+		return nil
+	}
 
-	for {
-		select {
-		case <-ctx.Done():
-			logger.Infof("checker job got exit sign. return now")
-			return
+	s := state.GetState().Get()
+	last := s.LocalLastPush
+	if s.RemoteLastPush > last {
+		last = s.RemoteLastPush
+	}
+	if last == 0 {
+		return nil
+	}
 
-		case <-time.After(time.Second * 1):
-			// 5 second buffer between range
-			checker()
+	var err error
+	found := 0
+	for ; found < probeBatch && ctx.Err() == nil; found++ {
+		if _, err = f.Fetch(ctx, last+uint(found)+1); err != nil {
+			break
 		}
 	}
+	if errors.Is(err, merklefetch.ErrNotExist) {
+		err = nil
+	}
+
+	if found > 0 {
+		probeState.misses = 0
+		probeState.next = time.Time{}
+		return err
+	}
+	wait := probeBackoff << probeState.misses
+	if wait > probeMaxBackoff {
+		wait = probeMaxBackoff
+	} else {
+		probeState.misses++
+	}
+	probeState.next = time.Now().Add(wait)
+	return err
 }
 
-func checker() {
-	logger.Info("checker running")
+// walk 从上次保存的游标开始向前检查区块，直到两侧已知的最高区块
+// 两侧都缺失的区块记为 CHECK_STATUS_MISSING_BOTH 后继续向前，由 recheck 重新检查
+func walk(ctx context.Context, db *gorm.DB) error {
+	st := state.GetState()
+	s := st.Get()
+
+	next := s.CheckerCursor + 1
+	if s.CheckerCursor == 0 {
+		if next = firstHeight(); next == 0 {
+			return nil
+		}
+	}
+	last := s.LocalLastPush
+	if s.RemoteLastPush > last {
+		last = s.RemoteLastPush
+	}
+
+	for i := 0; i < walkBatch && next <= last; i++ {
+		if ctx.Err() != nil {
+			return nil
+		}
 
+		check, err := checkBlock(ctx, next)
+		if err != nil {
+			return err
+		}
+		if check.Status == constant.CHECK_STATUS_DEFAULT {
+			// 远端可能有空洞，停在这里会让后面的区块永远不被检查
+			check.Status = constant.CHECK_STATUS_MISSING_BOTH
+		}
+		if err = dao.SaveBlockCheck(db, check); err != nil {
+			return err
+		}
+		if err = st.SetCheckerCursor(next); err != nil {
+			return err
+		}
+		next++
+	}
+	return nil
+}
+
+// firstHeight 本地与远端镜像中最低的区块高度，都没有数据时返回 0
+func firstHeight() uint {
+	first := state.FirstLocalHeight()
+	if r := merklestore.GetRemote(); r != nil {
+		if heights := r.Heights(); len(heights) > 0 && (first == 0 || heights[0] < first) {
+			first = heights[0]
+		}
+	}
+	return first
+}
+
+// recheck 从上一批之后继续重新检查之前缺失的区块，到末尾后从头开始
+// 一直缺失的区块不会挡住后面的区块；没有配置远端时不重新检查 CHECK_STATUS_MISSING_REMOTE
+func recheck(ctx context.Context, db *gorm.DB) error {
+	status := []int{constant.CHECK_STATUS_MISSING_LOCAL, constant.CHECK_STATUS_MISSING_BOTH}
+	if merklestore.GetRemote() != nil {
+		status = append(status, constant.CHECK_STATUS_MISSING_REMOTE)
+	}
+
+	st := state.GetState()
+	cursor := st.Get().RecheckCursor
+	missing, err := dao.ListBlockChecksByStatus(db, status, cursor, recheckBatch)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range missing {
+		if ctx.Err() != nil {
+			return st.SetRecheckCursor(cursor)
+		}
+
+		check, err := checkBlock(ctx, m.Number)
+		if err != nil {
+			return errors.Join(err, st.SetRecheckCursor(cursor))
+		}
+		if check.Status != m.Status && check.Status != constant.CHECK_STATUS_DEFAULT {
+			if err = dao.SaveBlockCheck(db, check); err != nil {
+				return errors.Join(err, st.SetRecheckCursor(cursor))
+			}
+		}
+		cursor = m.Number
+	}
+	// 不满一批说明已经到末尾，下一批从头开始
+	if len(missing) < recheckBatch {
+		cursor = 0
+	}
+	return st.SetRecheckCursor(cursor)
+}
+
+// checkBlock 比较区块本地与远端的 Merkle 根，两侧都不存在时状态为 CHECK_STATUS_DEFAULT
+func checkBlock(ctx context.Context, block uint) (dao.BlockCheck, error) {
+	ret := dao.BlockCheck{Number: block}
+
+	local, err := loadFile(merklestore.GetLocal(), block)
+	if err != nil {
+		return ret, err
+	}
+
+	if f := merklefetch.GetFetcher(); f != nil {
+		if _, err = f.Fetch(ctx, block); err != nil && !errors.Is(err, merklefetch.ErrNotExist) {
+			return ret, err
+		}
+	}
+	remote, err := loadFile(merklestore.GetRemote(), block)
+	if err != nil {
+		return ret, err
+	}
+
+	if local != nil {
+		ret.Hash = local.Hash
+		ret.LocalRoot = local.Root
+	}
+	if remote != nil {
+		ret.RemoteRoot = remote.Root
+	}
+
+	switch {
+	case local == nil && remote == nil:
+		ret.Status = constant.CHECK_STATUS_DEFAULT
+	case local == nil:
+		ret.Status = constant.CHECK_STATUS_MISSING_LOCAL
+	case remote == nil:
+		ret.Status = constant.CHECK_STATUS_MISSING_REMOTE
+	case local.Root == remote.Root:
+		ret.Status = constant.CHECK_STATUS_MATCHED
+	default:
+		ret.Status = constant.CHECK_STATUS_MISMATCHED
+	}
+	return ret, nil
+}
+
+// loadFile 文件不存在或没有配置该存储时返回 nil
+func loadFile(s *merklestore.Store, block uint) (*merklestore.File, error) {
+	if s == nil {
+		return nil, nil
+	}
+	f, err := s.Get(block)
+	if errors.Is(err, merklestore.ErrNotExist) {
+		return nil, nil
+	}
+	return f, err
 }
@@ -0,0 +1,175 @@
+package checker_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"web/config"
+	"web/constant"
+	"web/dao"
+	"web/jobs/checker"
+	"web/logger"
+	"web/repository/merklefetch"
+	"web/repository/merklestore"
+	"web/repository/pg"
+	"web/repository/state"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+func init() {
+	logger.ErrorLogger = zap.NewNop().Sugar()
+}
+
+func setup(t *testing.T) *gorm.DB {
+	dir := t.TempDir()
+	var cfg config.Configuration
+	cfg.MerkleSetting.FilePath = filepath.Join(dir, "local")
+	cfg.MerkleSetting.RemotePath = filepath.Join(dir, "remote")
+	cfg.RuntimeSetting.RuntimePath = dir
+	cfg.PostgreCfg.Driver = "sqlite3"
+	cfg.PostgreCfg.Conf = map[string]string{constant.DBNameMain: filepath.Join(dir, "main.db")}
+	cfg.Log.LogPath = dir
+	pg.InitPg(cfg)
+	merklestore.InitMerkleStore(cfg)
+	state.InitState(cfg)
+
+	db, err := pg.GetDB(constant.DBNameMain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = dao.AutoMigrate(db); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func put(t *testing.T, s *merklestore.Store, blocks ...uint) {
+	for _, b := range blocks {
+		if _, err := s.Put(merklestore.NewFile(b, []string{"ins"}, nil)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := state.GetState().ObserveRemote(blocks[len(blocks)-1]); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func statuses(t *testing.T, db *gorm.DB) map[uint]int {
+	checks, err := dao.ListLatestBlockChecks(db, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ret := make(map[uint]int, len(checks))
+	for _, c := range checks {
+		ret[c.Number] = c.Status
+	}
+	return ret
+}
+
+func TestWalkSkipsGap(t *testing.T) {
+	db := setup(t)
+	put(t, merklestore.GetLocal(), 1, 2, 4)
+	put(t, merklestore.GetRemote(), 1, 2, 4)
+
+	if err := checker.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	got := statuses(t, db)
+	if got[3] != constant.CHECK_STATUS_MISSING_BOTH || got[4] != constant.CHECK_STATUS_MATCHED {
+		t.Fatalf("unexpected statuses %v", got)
+	}
+	if cursor := state.GetState().Get().CheckerCursor; cursor != 4 {
+		t.Fatalf("cursor should pass the gap, got %d", cursor)
+	}
+
+	// 空洞补齐后由 recheck 更新
+	put(t, merklestore.GetLocal(), 3)
+	put(t, merklestore.GetRemote(), 3)
+	if err := checker.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got = statuses(t, db); got[3] != constant.CHECK_STATUS_MATCHED {
+		t.Fatalf("gap not rechecked %v", got)
+	}
+}
+
+func TestWalkRemoteOnly(t *testing.T) {
+	db := setup(t)
+	put(t, merklestore.GetRemote(), 5, 6)
+
+	if err := checker.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	got := statuses(t, db)
+	if len(got) != 2 || got[5] != constant.CHECK_STATUS_MISSING_LOCAL || got[6] != constant.CHECK_STATUS_MISSING_LOCAL {
+		t.Fatalf("unexpected statuses %v", got)
+	}
+	if cursor := state.GetState().Get().CheckerCursor; cursor != 6 {
+		t.Fatalf("unexpected cursor %d", cursor)
+	}
+}
+
+func TestRecheckPastMissing(t *testing.T) {
+	db := setup(t)
+	blocks := make([]uint, 0, 30)
+	for b := uint(1); b <= 30; b++ {
+		blocks = append(blocks, b)
+	}
+	put(t, merklestore.GetLocal(), blocks...)
+
+	if err := checker.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := statuses(t, db); len(got) != 30 || got[25] != constant.CHECK_STATUS_MISSING_REMOTE {
+		t.Fatalf("unexpected statuses %v", got)
+	}
+
+	// 前 20 个区块一直缺失，后面的区块仍然会被重新检查
+	put(t, merklestore.GetRemote(), 25)
+	for i := 0; i < 2; i++ {
+		if err := checker.Run(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got := statuses(t, db)
+	if got[25] != constant.CHECK_STATUS_MATCHED || got[1] != constant.CHECK_STATUS_MISSING_REMOTE {
+		t.Fatalf("block 25 not rechecked %v", got)
+	}
+	if cursor := state.GetState().Get().RecheckCursor; cursor != 0 {
+		t.Fatalf("recheck cursor should wrap, got %d", cursor)
+	}
+}
+
+func TestProbeRemoteAhead(t *testing.T) {
+	db := setup(t)
+	put(t, merklestore.GetLocal(), 1, 2, 3)
+
+	// 远端领先本地 3 个区块
+	src, err := merklestore.New(t.TempDir(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer src.Close()
+	for b := uint(1); b <= 6; b++ {
+		if _, err = src.Put(merklestore.NewFile(b, []string{"ins"}, nil)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var cfg config.Configuration
+	cfg.MerkleSetting.RemoteSource = src.Root()
+	merklefetch.InitFetcher(cfg)
+	defer merklefetch.InitFetcher(config.Configuration{})
+
+	if err = checker.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if last := state.GetState().Get().RemoteLastPush; last != 6 {
+		t.Fatalf("remote ahead not observed, remote_last_push %d", last)
+	}
+	got := statuses(t, db)
+	if got[3] != constant.CHECK_STATUS_MATCHED || got[6] != constant.CHECK_STATUS_MISSING_LOCAL {
+		t.Fatalf("unexpected statuses %v", got)
+	}
+}
@@ -0,0 +1,46 @@
+package jobs_test
+
+import (
+	"testing"
+	"time"
+	"web/jobs"
+)
+
+func TestParseCron(t *testing.T) {
+	base := time.Date(2024, 2, 28, 10, 7, 30, 0, time.Local) // 周三
+	cases := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2024, 2, 28, 10, 8, 0, 0, time.Local)},
+		{"*/15 * * * *", time.Date(2024, 2, 28, 10, 15, 0, 0, time.Local)},
+		{"5 10-12 * * *", time.Date(2024, 2, 28, 11, 5, 0, 0, time.Local)},
+		{"0 0 29 2 *", time.Date(2024, 2, 29, 0, 0, 0, 0, time.Local)},
+		{"0 9 * * 1,7", time.Date(2024, 3, 3, 9, 0, 0, 0, time.Local)},
+		{"@daily", time.Date(2024, 2, 29, 0, 0, 0, 0, time.Local)},
+		{"@every 90s", base.Add(90 * time.Second)},
+		// 日与周都指定时满足其一即可
+		{"0 0 1 * 4", time.Date(2024, 2, 29, 0, 0, 0, 0, time.Local)},
+	}
+	for _, c := range cases {
+		s, err := jobs.ParseCron(c.expr)
+		if err != nil {
+			t.Fatalf("%s: %v", c.expr, err)
+		}
+		if got := s.Next(base); !got.Equal(c.want) {
+			t.Errorf("%s: got %v want %v", c.expr, got, c.want)
+		}
+	}
+
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "*/0 * * * *", "a * * * *", "5-1 * * * *", "@every -1s"} {
+		if _, err := jobs.ParseCron(expr); err == nil {
+			t.Errorf("%q accepted", expr)
+		}
+	}
+
+	// 永远不会满足
+	s, _ := jobs.ParseCron("0 0 30 2 *")
+	if !s.Next(base).IsZero() {
+		t.Error("impossible expression scheduled")
+	}
+}
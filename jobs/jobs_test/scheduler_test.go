@@ -0,0 +1,235 @@
+package jobs_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+	"web/jobs"
+	"web/logger"
+
+	"go.uber.org/zap"
+)
+
+func init() {
+	logger.ErrorLogger = zap.NewNop().Sugar()
+}
+
+func start(t *testing.T, s *jobs.Scheduler) func() {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.Run(ctx)
+		close(done)
+	}()
+	return func() {
+		cancel()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("scheduler not stopped")
+		}
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timeout")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestInterval(t *testing.T) {
+	var n atomic.Int32
+	s := jobs.NewScheduler()
+	err := s.Register(jobs.Job{Name: "tick", Interval: 10 * time.Millisecond, Run: func(ctx context.Context) error {
+		n.Add(1)
+		return nil
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stop := start(t, s)
+	waitFor(t, func() bool { return n.Load() >= 3 })
+	stop()
+
+	st, _ := s.Get("tick")
+	if st.LastRun == nil || st.LastRun.Trigger != jobs.TriggerSchedule || st.LastRun.RequestID == "" {
+		t.Fatalf("last run %+v", st.LastRun)
+	}
+	if len(st.History) == 0 || st.History[0] != *st.LastRun {
+		t.Fatal("history not newest first")
+	}
+}
+
+func TestSkipAndTrigger(t *testing.T) {
+	release := make(chan struct{})
+	var n atomic.Int32
+	s := jobs.NewScheduler()
+	_ = s.Register(jobs.Job{Name: "slow", Interval: 5 * time.Millisecond, Run: func(ctx context.Context) error {
+		n.Add(1)
+		<-release
+		return nil
+	}})
+	if err := s.Trigger("slow"); !errors.Is(err, jobs.ErrNotStarted) {
+		t.Fatalf("err %v", err)
+	}
+
+	stop := start(t, s)
+	defer stop()
+	waitFor(t, func() bool {
+		st, _ := s.Get("slow")
+		return st.Running && st.Skipped > 0
+	})
+	if err := s.Trigger("slow"); !errors.Is(err, jobs.ErrJobRunning) {
+		t.Fatalf("err %v", err)
+	}
+	if n.Load() != 1 {
+		t.Fatalf("overlapped runs %d", n.Load())
+	}
+	if err := s.Trigger("missing"); !errors.Is(err, jobs.ErrJobNotFound) {
+		t.Fatalf("err %v", err)
+	}
+	close(release)
+}
+
+func TestPauseAndManual(t *testing.T) {
+	var n atomic.Int32
+	s := jobs.NewScheduler()
+	_ = s.Register(jobs.Job{Name: "paused", Interval: 5 * time.Millisecond, Paused: true, Run: func(ctx context.Context) error {
+		n.Add(1)
+		return nil
+	}})
+	stop := start(t, s)
+	defer stop()
+
+	time.Sleep(30 * time.Millisecond)
+	if n.Load() != 0 {
+		t.Fatal("paused job ran")
+	}
+
+	// 暂停时也可以手动触发
+	waitFor(t, func() bool { return s.Trigger("paused") == nil })
+	waitFor(t, func() bool { return n.Load() == 1 })
+	var st jobs.Status
+	waitFor(t, func() bool { st, _ = s.Get("paused"); return st.LastRun != nil })
+	if st.LastRun.Trigger != jobs.TriggerManual {
+		t.Fatalf("trigger %s", st.LastRun.Trigger)
+	}
+
+	_ = s.Pause("paused", false)
+	waitFor(t, func() bool { return n.Load() >= 3 })
+}
+
+func TestPanicBackoff(t *testing.T) {
+	var n atomic.Int32
+	s := jobs.NewScheduler(jobs.WithBackoff(100*time.Millisecond, time.Second))
+	_ = s.Register(jobs.Job{Name: "panic", Interval: time.Millisecond, Run: func(ctx context.Context) error {
+		n.Add(1)
+		panic("boom")
+	}})
+	stop := start(t, s)
+	defer stop()
+
+	waitFor(t, func() bool {
+		st, _ := s.Get("panic")
+		return st.Failures == 1
+	})
+	time.Sleep(50 * time.Millisecond)
+	// 第一次失败后至少推迟 100ms
+	if n.Load() != 1 {
+		t.Fatalf("runs %d during backoff", n.Load())
+	}
+	st, _ := s.Get("panic")
+	if !st.LastRun.Panic || st.LastRun.Err != "boom" {
+		t.Fatalf("last run %+v", st.LastRun)
+	}
+	if time.Until(st.NextRun) < 30*time.Millisecond {
+		t.Fatalf("next run %v", time.Until(st.NextRun))
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	s := jobs.NewScheduler()
+	_ = s.Register(jobs.Job{Name: "timeout", Interval: time.Hour, Timeout: 10 * time.Millisecond, Run: func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}})
+	stop := start(t, s)
+	defer stop()
+
+	waitFor(t, func() bool { return s.Trigger("timeout") == nil })
+	waitFor(t, func() bool {
+		st, _ := s.Get("timeout")
+		return st.LastRun != nil && st.LastRun.Err == context.DeadlineExceeded.Error()
+	})
+}
+
+func TestRegister(t *testing.T) {
+	s := jobs.NewScheduler()
+	run := func(context.Context) error { return nil }
+	if err := s.Register(jobs.Job{Name: "a", Run: run}); err == nil {
+		t.Fatal("job without schedule accepted")
+	}
+	if err := s.Register(jobs.Job{Name: "a", Interval: time.Second, Run: run}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Register(jobs.Job{Name: "a", Interval: time.Second, Run: run}); err == nil {
+		t.Fatal("duplicate accepted")
+	}
+}
+
+type leadership struct {
+	leader atomic.Bool
+	ctx    context.Context
+}
+
+func (l *leadership) IsLeader() bool {
+	return l.leader.Load()
+}
+
+func (l *leadership) Hold() (context.Context, func(), bool) {
+	if !l.leader.Load() {
+		return nil, nil, false
+	}
+	return l.ctx, func() {}, true
+}
+
+func TestLeaderOnly(t *testing.T) {
+	lctx, resign := context.WithCancel(context.Background())
+	l := &leadership{ctx: lctx}
+	var n atomic.Int32
+	s := jobs.NewScheduler(jobs.WithLeadership(l))
+	_ = s.Register(jobs.Job{Name: "leader", Interval: 5 * time.Millisecond, LeaderOnly: true, Run: func(ctx context.Context) error {
+		n.Add(1)
+		<-ctx.Done()
+		return ctx.Err()
+	}})
+	stop := start(t, s)
+	defer stop()
+
+	waitFor(t, func() bool { return s.Trigger("leader") != jobs.ErrNotStarted })
+	if err := s.Trigger("leader"); !errors.Is(err, jobs.ErrNotLeader) {
+		t.Fatalf("err %v", err)
+	}
+	time.Sleep(30 * time.Millisecond)
+	if n.Load() != 0 {
+		t.Fatal("follower ran leader only job")
+	}
+
+	l.leader.Store(true)
+	waitFor(t, func() bool { return n.Load() == 1 })
+
+	// 失去 leader 时取消进行中的执行
+	l.leader.Store(false)
+	resign()
+	waitFor(t, func() bool {
+		st, _ := s.Get("leader")
+		return st.LastRun != nil && st.LastRun.Err == context.Canceled.Error()
+	})
+}
@@ -0,0 +1,201 @@
+package puller
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"web/brc20"
+	"web/config"
+	"web/constant"
+	webctx "web/context"
+	"web/dao"
+	"web/repository/blocksource"
+	"web/repository/chain"
+	"web/repository/merklestore"
+	"web/repository/pg"
+	"web/repository/state"
+
+	"gorm.io/gorm"
+)
+
+const (
+	defaultInterval = 10 * time.Second
+	// 每次最多处理的区块数
+	pullBatch = 100
+)
+
+var (
+	mu     sync.Mutex
+	puller *Puller
+)
+
+// Enabled 配置了区块来源时才需要运行
+func Enabled() bool {
+	return blocksource.GetSource() != nil
+}
+
+// Interval 配置的拉取间隔
+func Interval() time.Duration {
+	interval := config.Configure.BlockSource.Interval * time.Second
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+	return interval
+}
+
+// Run 拉取一批区块，由 jobs 调度器定时调用，第一次执行时从数据库恢复引擎状态
+func Run(ctx context.Context) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if puller == nil {
+		db, err := pg.GetDB(constant.DBNameMain)
+		if err != nil {
+			return err
+		}
+		first := config.Configure.Brc20Setting.FirstHeight
+		if first == 0 {
+			first = constant.FIRST_BRC20_Block
+		}
+		p, err := New(blocksource.GetSource(), db, first, brc20.ConfigOptions(config.Configure.Brc20Setting)...)
+		if err != nil {
+			return fmt.Errorf("load brc20 state: %w", err)
+		}
+		puller = p
+	}
+	return puller.Pull(ctx)
+}
+
+// Puller 从 BlockSource 拉取区块，处理 BRC-20 事件并构建 Merkle 文件
+type Puller struct {
+	source blocksource.BlockSource
+	db     *gorm.DB
+	first  uint
+	opts   []brc20.Option
+
+	engine *brc20.Engine
+	// engine 对应的最高区块，与数据库不一致时说明其他实例写入过，需要重新加载
+	height uint
+}
+
+// New first 为没有处理记录时开始拉取的区块，引擎状态从数据库恢复
+func New(source blocksource.BlockSource, db *gorm.DB, first uint, opts ...brc20.Option) (*Puller, error) {
+	p := &Puller{
+		source: source,
+		db:     db,
+		first:  first,
+		opts:   opts,
+	}
+	if err := p.reload(); err != nil {
+		return nil, err
+	}
+	if t, ok := source.(blocksource.PendingTracker); ok {
+		// reload 会替换 engine，每次都读取当前的 engine
+		t.SetPending(func() []string { return p.engine.PendingTransfers() })
+	}
+	return p, nil
+}
+
+// Pull 处理已处理的最高区块之后到主链最高区块之间的区块，每次最多 pullBatch 个
+func (p *Puller) Pull(ctx context.Context) error {
+	tip, err := p.source.Height(ctx)
+	if err != nil {
+		return err
+	}
+	last, err := dao.LastBrc20Block(p.db.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	if last != p.height {
+		if err = p.reload(); err != nil {
+			return err
+		}
+	}
+	next := p.nextAfter(last)
+
+	for i := 0; i < pullBatch && next <= tip; i++ {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		b, err := p.source.Block(ctx, next)
+		if err != nil {
+			return err
+		}
+
+		// 检查重组与写入在同一把锁内，推送的区块不会插在中间
+		reorg, err := chain.Commit(ctx, next, b.Hash, b.PrevHash, func(reorg *chain.Reorg) error {
+			if reorg != nil {
+				// 数据库中的状态已经回退，重新加载后接在分叉点之后处理
+				if err := p.reload(); err != nil {
+					return err
+				}
+			}
+			return p.process(ctx, b)
+		})
+		if err != nil {
+			// 丢弃内存中未保存的修改，不使用 ctx，取消后也要能恢复
+			if e := p.reload(); e != nil {
+				webctx.Logger(ctx).Errorf("reload brc20 state failed. [err:%v]", e)
+			}
+			return err
+		}
+		if reorg != nil && reorg.Next() < next {
+			// 数据库中的状态已经回退，重新加载后从分叉点继续
+			if err = p.reload(); err != nil {
+				return err
+			}
+			if next = reorg.Next(); next < p.first {
+				next = p.first
+			}
+			continue
+		}
+		next++
+	}
+	return nil
+}
+
+// process 先写 Merkle 文件再提交数据库，数据库写入失败后重新拉取时会覆盖文件
+func (p *Puller) process(ctx context.Context, b blocksource.Block) error {
+	results := p.engine.ApplyBlock(b.Height, b.Events)
+
+	ins, trx := brc20.Leaves(results)
+	f := merklestore.NewFile(b.Height, ins, trx)
+	f.Hash, f.PrevHash = b.Hash, b.PrevHash
+	if _, err := merklestore.GetLocal().Put(f); err != nil {
+		return err
+	}
+
+	if err := dao.SaveBrc20Block(p.db.WithContext(ctx), b.Height, results, p.engine.Flush()); err != nil {
+		return err
+	}
+	p.height = b.Height
+
+	if err := state.GetState().AdvanceLocal(state.FirstLocalHeight, merklestore.GetLocal().Has); err != nil {
+		webctx.Logger(ctx).Errorf("update local last push failed. [block:%d] [err:%v]", b.Height, err)
+	}
+	webctx.Logger(ctx).Infof("pull block success. [block:%d] [hash:%s] [events:%d] [root:%s]", b.Height, b.Hash, len(b.Events), f.Root)
+	return nil
+}
+
+func (p *Puller) reload() error {
+	height, err := dao.LastBrc20Block(p.db)
+	if err != nil {
+		return err
+	}
+	e, err := dao.LoadBrc20Engine(p.db, p.opts...)
+	if err != nil {
+		return err
+	}
+	p.engine, p.height = e, height
+	return nil
+}
+
+func (p *Puller) nextAfter(block uint) uint {
+	if block < p.first {
+		return p.first
+	}
+	return block + 1
+}
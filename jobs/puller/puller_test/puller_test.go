@@ -0,0 +1,125 @@
+package puller_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"web/brc20"
+	"web/config"
+	"web/constant"
+	"web/dao"
+	"web/jobs/puller"
+	"web/logger"
+	"web/repository/blocksource"
+	"web/repository/chain"
+	"web/repository/merklestore"
+	"web/repository/pg"
+	"web/repository/state"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+func init() {
+	logger.ErrorLogger = zap.NewNop().Sugar()
+}
+
+func setup(t *testing.T) *gorm.DB {
+	dir := t.TempDir()
+	var cfg config.Configuration
+	cfg.MerkleSetting.FilePath = filepath.Join(dir, "local")
+	cfg.MerkleSetting.RemotePath = filepath.Join(dir, "remote")
+	cfg.RuntimeSetting.RuntimePath = dir
+	cfg.PostgreCfg.Driver = "sqlite3"
+	cfg.PostgreCfg.Conf = map[string]string{constant.DBNameMain: filepath.Join(dir, "main.db")}
+	cfg.Log.LogPath = dir
+	pg.InitPg(cfg)
+	merklestore.InitMerkleStore(cfg)
+	state.InitState(cfg)
+
+	db, err := pg.GetDB(constant.DBNameMain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = dao.AutoMigrate(db); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// replay 写入 fork 链上 [1, tip] 的区块，3 之后从链 a 分叉
+// 每个区块给链名对应的地址 mint 1 个 ordi
+func replay(t *testing.T, fork string, tip uint) blocksource.BlockSource {
+	name := func(h uint) string {
+		if h <= 3 {
+			return "a"
+		}
+		return fork
+	}
+	hash := func(h uint) string {
+		return fmt.Sprintf("%s-%d", name(h), h)
+	}
+
+	path := filepath.Join(t.TempDir(), "blocks.jsonl")
+	f, _ := os.Create(path)
+	enc := json.NewEncoder(f)
+	for h := uint(1); h <= tip; h++ {
+		b := blocksource.Block{Height: h, Hash: hash(h), PrevHash: hash(h - 1)}
+		if h == 1 {
+			b.Events = append(b.Events, brc20.Event{Op: constant.BRC20_OP_DEPLOY, Tick: "ordi", Max: "1000", InscriptionID: "d"})
+		}
+		b.Events = append(b.Events, brc20.Event{Op: constant.BRC20_OP_MINT, Tick: "ordi", To: name(h), Amt: "1"})
+		_ = enc.Encode(b)
+	}
+	f.Close()
+
+	s, err := blocksource.NewReplaySource(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain.SetHashSource(s.BlockHash)
+	return s
+}
+
+func TestPullWithReorg(t *testing.T) {
+	db := setup(t)
+	ctx := context.Background()
+
+	p, err := puller.New(replay(t, "a", 5), db, 1, brc20.WithFirstHeight(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = p.Pull(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if last, _ := dao.LastBrc20Block(db); last != 5 || state.GetState().Get().LocalLastPush != 5 {
+		t.Fatalf("unexpected progress %d %+v", last, state.GetState().Get())
+	}
+
+	// 新链从 3 之后分叉
+	p, err = puller.New(replay(t, "b", 6), db, 1, brc20.WithFirstHeight(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = p.Pull(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	e, err := dao.LoadBrc20Engine(db, brc20.WithFirstHeight(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a, b := e.Balance("ordi", "a"), e.Balance("ordi", "b"); a.Available.String() != "3" || b.Available.String() != "3" {
+		t.Fatalf("unexpected balances a=%s b=%s", a.Available, b.Available)
+	}
+	f, err := merklestore.GetLocal().Get(4)
+	if err != nil || f.Hash != "b-4" {
+		t.Fatalf("block 4 should be rebuilt on the new chain %+v (%v)", f, err)
+	}
+	if st := state.GetState().Get(); st.LocalLastPush != 6 {
+		t.Fatalf("unexpected state %+v", st)
+	}
+}
@@ -2,9 +2,91 @@ package jobs
 
 import (
 	"context"
+	"fmt"
+	"time"
+	"web/config"
+	"web/constant"
 	"web/jobs/checker"
+	"web/jobs/puller"
+	"web/logger"
+	"web/repository/leader"
+	"web/repository/pg"
 )
 
+var scheduler *Scheduler
+
+// InitScheduler 注册所有任务，config 中的 jobs 按任务名覆盖默认设置
+// 需要在 leader.InitLeader 之后调用
+func InitScheduler(config config.Configuration) error {
+	var opts []Option
+	if l := leader.GetLeader(); l != nil {
+		opts = append(opts, WithLeadership(l))
+	}
+	s := NewScheduler(opts...)
+	for _, job := range defaultJobs() {
+		if err := applyConfig(&job, config.JobSetting[job.Name]); err != nil {
+			return err
+		}
+		if err := s.Register(job); err != nil {
+			return err
+		}
+	}
+	for name := range config.JobSetting {
+		if _, err := s.Get(name); err != nil {
+			logger.Warnf("job in config not registered, ignore. [job:%s]", name)
+		}
+	}
+	scheduler = s
+	return nil
+}
+
+// GetScheduler 未初始化时返回 nil
+func GetScheduler() *Scheduler {
+	return scheduler
+}
+
+// RunJob 运行所有任务，ctx 取消后等待任务全部退出再返回
 func RunJob(ctx context.Context) {
-	go checker.CheckerJob(ctx)
+	if scheduler == nil {
+		return
+	}
+	scheduler.Run(ctx)
+}
+
+func defaultJobs() []Job {
+	if _, err := pg.GetDB(constant.DBNameMain); err != nil {
+		logger.Warnf("jobs need db %s, no job registered. [err:%v]", constant.DBNameMain, err)
+		return nil
+	}
+
+	jobs := []Job{
+		// 多个实例时只由 leader 写入检查结果与 BRC-20 状态
+		{Name: "checker", Interval: time.Second, Timeout: time.Minute, LeaderOnly: true, Run: checker.Run},
+	}
+	if puller.Enabled() {
+		jobs = append(jobs, Job{Name: "puller", Interval: puller.Interval(), Timeout: 10 * time.Minute, LeaderOnly: true, Run: puller.Run})
+	} else {
+		logger.Info("block source not configured, puller job not registered.")
+	}
+	return jobs
+}
+
+func applyConfig(job *Job, cfg config.Job) error {
+	if cfg.Cron != "" {
+		sch, err := ParseCron(cfg.Cron)
+		if err != nil {
+			return fmt.Errorf("job %s: %w", job.Name, err)
+		}
+		job.Schedule = sch
+	} else if cfg.Interval > 0 {
+		job.Interval = cfg.Interval * time.Second
+	}
+	if cfg.Jitter > 0 {
+		job.Jitter = cfg.Jitter * time.Second
+	}
+	if cfg.Timeout > 0 {
+		job.Timeout = cfg.Timeout * time.Second
+	}
+	job.Paused = cfg.Paused
+	return nil
 }
@@ -0,0 +1,437 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	webctx "web/context"
+)
+
+/*
+	scheduler 按间隔或 cron 表达式运行任务
+	1. 每个任务一个调度 goroutine，执行时间加上 [0, Jitter) 的随机抖动
+	2. 上一次还没结束时本次跳过，Timeout 大于 0 时作为单次执行的截止时间
+	3. 执行失败或 panic 后按 backoff 推迟下一次执行，成功后恢复
+	4. 保存最近 historySize 次执行记录，可以手动触发、暂停与恢复
+	5. LeaderOnly 的任务只在本实例是 leader 时执行，失去 leader 时取消进行中的执行
+*/
+
+const (
+	historySize = 20
+
+	TriggerSchedule = "schedule"
+	TriggerManual   = "manual"
+)
+
+var (
+	ErrJobNotFound = errors.New("job not found")
+	ErrJobRunning  = errors.New("job is running")
+	ErrNotStarted  = errors.New("scheduler not started")
+	ErrNotLeader   = errors.New("not leader")
+)
+
+// Leadership leader 选举，见 repository/leader
+type Leadership interface {
+	IsLeader() bool
+	// Hold 成为 leader 时返回 leader context，用完后调用 release
+	Hold() (ctx context.Context, release func(), ok bool)
+}
+
+// Job 任务声明，Schedule 为空时使用 Interval
+type Job struct {
+	Name     string
+	Schedule Schedule
+	Interval time.Duration
+	Jitter   time.Duration
+	Timeout  time.Duration
+	// Paused 启动时处于暂停状态
+	Paused bool
+	// LeaderOnly 多实例部署时只在 leader 上执行
+	LeaderOnly bool
+	Run        func(ctx context.Context) error
+}
+
+// Run 单次执行的记录
+type Run struct {
+	Trigger   string
+	RequestID string
+	Start     time.Time
+	Duration  time.Duration
+	Err       string
+	Panic     bool
+}
+
+// Status 任务的当前状态，History 按时间倒序
+type Status struct {
+	Name       string
+	Schedule   string
+	LeaderOnly bool
+	Paused     bool
+	Running    bool
+	NextRun    time.Time
+	LastRun    *Run
+	Failures   int
+	Skipped    int
+	History    []Run
+}
+
+type entry struct {
+	job     Job
+	trigger chan struct{}
+	// 执行结束后重新计算下一次执行时间
+	done chan struct{}
+
+	mu       sync.Mutex
+	paused   bool
+	running  bool
+	next     time.Time
+	failures int
+	skipped  int
+	history  []Run
+}
+
+type Scheduler struct {
+	mu      sync.Mutex
+	entries map[string]*entry
+	order   []string
+	started bool
+
+	// 失败后推迟执行的初始值与上限
+	backoff    time.Duration
+	maxBackoff time.Duration
+
+	leadership Leadership
+
+	wg sync.WaitGroup
+}
+
+type Option func(s *Scheduler)
+
+// WithBackoff 设置失败后推迟执行的初始值与上限
+func WithBackoff(base, max time.Duration) Option {
+	return func(s *Scheduler) {
+		s.backoff, s.maxBackoff = base, max
+	}
+}
+
+// WithLeadership 设置 leader 选举，不设置时 LeaderOnly 的任务总是执行
+func WithLeadership(l Leadership) Option {
+	return func(s *Scheduler) {
+		s.leadership = l
+	}
+}
+
+func NewScheduler(opts ...Option) *Scheduler {
+	s := &Scheduler{
+		entries:    make(map[string]*entry),
+		backoff:    time.Second,
+		maxBackoff: 5 * time.Minute,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Register 注册任务，需要在 Run 之前调用
+func (s *Scheduler) Register(job Job) error {
+	if job.Name == "" || job.Run == nil {
+		return errors.New("job need name and run func")
+	}
+	if job.Schedule == nil {
+		if job.Interval <= 0 {
+			return fmt.Errorf("job %s: need schedule or interval", job.Name)
+		}
+		job.Schedule = Every(job.Interval)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.started {
+		return fmt.Errorf("job %s: scheduler already started", job.Name)
+	}
+	if _, ok := s.entries[job.Name]; ok {
+		return fmt.Errorf("job %s: duplicate", job.Name)
+	}
+	s.entries[job.Name] = &entry{
+		job:     job,
+		trigger: make(chan struct{}, 1),
+		done:    make(chan struct{}, 1),
+		paused:  job.Paused,
+	}
+	s.order = append(s.order, job.Name)
+	return nil
+}
+
+// Run 运行所有任务，ctx 取消后等待进行中的执行结束再返回
+func (s *Scheduler) Run(ctx context.Context) {
+	s.mu.Lock()
+	s.started = true
+	entries := make([]*entry, 0, len(s.order))
+	for _, name := range s.order {
+		entries = append(entries, s.entries[name])
+	}
+	s.mu.Unlock()
+
+	var loops sync.WaitGroup
+	for _, e := range entries {
+		loops.Add(1)
+		go func(e *entry) {
+			defer loops.Done()
+			s.loop(ctx, e)
+		}(e)
+	}
+	loops.Wait()
+	s.wg.Wait()
+}
+
+// Trigger 立即执行一次任务，暂停的任务也可以触发
+func (s *Scheduler) Trigger(name string) error {
+	e, err := s.entry(name)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	started := s.started
+	s.mu.Unlock()
+	if !started {
+		return ErrNotStarted
+	}
+
+	e.mu.Lock()
+	running := e.running
+	e.mu.Unlock()
+	if running {
+		return ErrJobRunning
+	}
+	if e.job.LeaderOnly && !s.Leading() {
+		return ErrNotLeader
+	}
+	select {
+	case e.trigger <- struct{}{}:
+	default:
+		// 已经有一次触发在排队
+	}
+	return nil
+}
+
+// Pause 暂停或恢复任务的定时执行，进行中的执行不受影响
+func (s *Scheduler) Pause(name string, paused bool) error {
+	e, err := s.entry(name)
+	if err != nil {
+		return err
+	}
+	e.mu.Lock()
+	e.paused = paused
+	e.mu.Unlock()
+	return nil
+}
+
+// Leading 没有设置选举或本实例是 leader
+func (s *Scheduler) Leading() bool {
+	return s.leadership == nil || s.leadership.IsLeader()
+}
+
+// List 按注册顺序返回所有任务的状态
+func (s *Scheduler) List() []Status {
+	s.mu.Lock()
+	entries := make([]*entry, 0, len(s.order))
+	for _, name := range s.order {
+		entries = append(entries, s.entries[name])
+	}
+	s.mu.Unlock()
+
+	ret := make([]Status, 0, len(entries))
+	for _, e := range entries {
+		ret = append(ret, e.status())
+	}
+	return ret
+}
+
+// Get 返回单个任务的状态
+func (s *Scheduler) Get(name string) (Status, error) {
+	e, err := s.entry(name)
+	if err != nil {
+		return Status{}, err
+	}
+	return e.status(), nil
+}
+
+func (s *Scheduler) entry(name string) (*entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.entries[name]
+	if !ok {
+		return nil, ErrJobNotFound
+	}
+	return e, nil
+}
+
+func (s *Scheduler) loop(ctx context.Context, e *entry) {
+	for {
+		// 表达式不会再满足时只等待手动触发
+		var timer *time.Timer
+		var fire <-chan time.Time
+		if next := s.nextRun(e, time.Now()); !next.IsZero() {
+			timer = time.NewTimer(time.Until(next))
+			fire = timer.C
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-fire:
+			e.mu.Lock()
+			paused := e.paused
+			e.mu.Unlock()
+			if !paused {
+				s.start(ctx, e, TriggerSchedule)
+			}
+		case <-e.trigger:
+			s.start(ctx, e, TriggerManual)
+		case <-e.done:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// nextRun 计算下一次执行时间，连续失败时至少推迟 backoff
+func (s *Scheduler) nextRun(e *entry, now time.Time) time.Time {
+	next := e.job.Schedule.Next(now)
+	if !next.IsZero() && e.job.Jitter > 0 {
+		next = next.Add(time.Duration(rand.Int63n(int64(e.job.Jitter))))
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.failures > 0 && !next.IsZero() {
+		b := s.backoff << (e.failures - 1)
+		if b > s.maxBackoff || b <= 0 {
+			b = s.maxBackoff
+		}
+		if at := now.Add(b); at.After(next) {
+			next = at
+		}
+	}
+	e.next = next
+	return next
+}
+
+// start 上一次执行还没结束时跳过，LeaderOnly 的任务不是 leader 时不执行
+func (s *Scheduler) start(ctx context.Context, e *entry, trigger string) {
+	var (
+		lctx    context.Context
+		release = func() {}
+	)
+	if e.job.LeaderOnly && s.leadership != nil {
+		var ok bool
+		if lctx, release, ok = s.leadership.Hold(); !ok {
+			return
+		}
+	}
+
+	e.mu.Lock()
+	if e.running {
+		e.skipped++
+		e.mu.Unlock()
+		release()
+		webctx.Logger(ctx).Warnf("job still running, skip. [job:%s] [trigger:%s]", e.job.Name, trigger)
+		return
+	}
+	e.running = true
+	e.mu.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		run := execute(ctx, lctx, e.job, trigger)
+		release()
+
+		e.mu.Lock()
+		e.running = false
+		if run.Err != "" {
+			e.failures++
+		} else {
+			e.failures = 0
+		}
+		e.history = append(e.history, run)
+		if len(e.history) > historySize {
+			e.history = e.history[len(e.history)-historySize:]
+		}
+		e.mu.Unlock()
+
+		select {
+		case e.done <- struct{}{}:
+		default:
+		}
+	}()
+}
+
+// execute 执行一次任务，panic 记为失败；lctx 不为空时随其一起取消
+func execute(parent, lctx context.Context, job Job, trigger string) (run Run) {
+	ctx, cancel := context.WithCancel(webctx.Background(parent, job.Name))
+	defer cancel()
+	if lctx != nil {
+		stop := context.AfterFunc(lctx, cancel)
+		defer stop()
+	}
+	if job.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, job.Timeout)
+		defer cancel()
+	}
+
+	run = Run{
+		Trigger:   trigger,
+		RequestID: webctx.RequestIDFrom(ctx),
+		Start:     time.Now(),
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			run.Panic = true
+			run.Err = fmt.Sprint(r)
+			webctx.Logger(ctx).Errorf("job panic. [job:%s] [err:%v]\n%s", job.Name, r, debug.Stack())
+		}
+		run.Duration = time.Since(run.Start)
+	}()
+
+	if err := job.Run(ctx); err != nil {
+		run.Err = err.Error()
+		webctx.Logger(ctx).Errorf("job failed. [job:%s] [err:%v]", job.Name, err)
+	}
+	return run
+}
+
+func (e *entry) status() Status {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	st := Status{
+		Name:       e.job.Name,
+		Schedule:   e.job.Schedule.String(),
+		LeaderOnly: e.job.LeaderOnly,
+		Paused:     e.paused,
+		Running:    e.running,
+		NextRun:    e.next,
+		Failures:   e.failures,
+		Skipped:    e.skipped,
+		History:    make([]Run, 0, len(e.history)),
+	}
+	for i := len(e.history) - 1; i >= 0; i-- {
+		st.History = append(st.History, e.history[i])
+	}
+	if len(st.History) > 0 {
+		last := st.History[0]
+		st.LastRun = &last
+	}
+	return st
+}
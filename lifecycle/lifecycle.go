@@ -0,0 +1,189 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"web/logger"
+)
+
+/*
+	lifecycle 管理进程内组件的启动与关闭
+	1. 组件注册 Start/Stop，通过 DependsOn 声明依赖，启动时依赖先启动
+	2. 关闭时按启动的逆序逐个停止，整体受 ctx 的截止时间限制，超时未停止的组件在返回的错误中列出
+	3. 运行期间组件可以调用 Fail 报告致命错误，Failed 返回的 channel 会收到该错误
+*/
+
+var ErrStopTimeout = errors.New("stop timeout")
+
+// Hook 组件的启动与关闭，Start/Stop 可以为空
+type Hook struct {
+	Name      string
+	DependsOn []string
+	// Start 不能阻塞，长期运行的组件自己启动 goroutine
+	Start func(ctx context.Context) error
+	Stop  func(ctx context.Context) error
+}
+
+type Manager struct {
+	mu      sync.Mutex
+	hooks   []Hook
+	started []Hook
+
+	failOnce sync.Once
+	failed   chan error
+}
+
+func New() *Manager {
+	return &Manager{failed: make(chan error, 1)}
+}
+
+// Append 注册组件，名称不能重复
+func (m *Manager) Append(h Hook) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if h.Name == "" {
+		return errors.New("lifecycle: hook without name")
+	}
+	for _, e := range m.hooks {
+		if e.Name == h.Name {
+			return fmt.Errorf("lifecycle: duplicate hook %s", h.Name)
+		}
+	}
+	m.hooks = append(m.hooks, h)
+	return nil
+}
+
+// Start 按依赖顺序启动所有组件，某个组件失败时立即返回，已启动的组件由调用方通过 Stop 停止
+func (m *Manager) Start(ctx context.Context) error {
+	m.mu.Lock()
+	ordered, err := sortHooks(m.hooks)
+	m.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	for _, h := range ordered {
+		if err = call(ctx, h.Start); err != nil {
+			err = fmt.Errorf("start %s: %w", h.Name, err)
+			logger.Errorf("lifecycle start failed. [component:%s] [err:%v]", h.Name, err)
+			return err
+		}
+		logger.Infof("lifecycle component started. [component:%s]", h.Name)
+
+		m.mu.Lock()
+		m.started = append(m.started, h)
+		m.mu.Unlock()
+	}
+	return nil
+}
+
+// Stop 逆序停止已启动的组件，ctx 到期后剩余组件不再等待，全部记为超时
+func (m *Manager) Stop(ctx context.Context) error {
+	m.mu.Lock()
+	started := m.started
+	m.started = nil
+	m.mu.Unlock()
+
+	var errs []error
+	for i := len(started) - 1; i >= 0; i-- {
+		h := started[i]
+		if ctx.Err() != nil {
+			errs = append(errs, fmt.Errorf("stop %s: %w", h.Name, ErrStopTimeout))
+			logger.Errorf("lifecycle component not stopped. [component:%s] [err:%v]", h.Name, ErrStopTimeout)
+			continue
+		}
+
+		done := make(chan error, 1)
+		go func() {
+			done <- call(ctx, h.Stop)
+		}()
+
+		var err error
+		select {
+		case err = <-done:
+		case <-ctx.Done():
+			err = ErrStopTimeout
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("stop %s: %w", h.Name, err))
+			logger.Errorf("lifecycle component not stopped. [component:%s] [err:%v]", h.Name, err)
+			continue
+		}
+		logger.Infof("lifecycle component stopped. [component:%s]", h.Name)
+	}
+	return errors.Join(errs...)
+}
+
+// Fail 组件运行期间报告致命错误，只保留第一个
+func (m *Manager) Fail(err error) {
+	m.failOnce.Do(func() {
+		m.failed <- err
+	})
+}
+
+// Failed 组件报告致命错误时收到该错误
+func (m *Manager) Failed() <-chan error {
+	return m.failed
+}
+
+// call 执行 Start/Stop，panic 转为错误
+func call(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	if fn == nil {
+		return nil
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v\n%s", r, debug.Stack())
+		}
+	}()
+	return fn(ctx)
+}
+
+// sortHooks 依赖在前，没有依赖关系的组件保持注册顺序
+func sortHooks(hooks []Hook) ([]Hook, error) {
+	byName := make(map[string]Hook, len(hooks))
+	for _, h := range hooks {
+		byName[h.Name] = h
+	}
+
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	mark := make(map[string]int, len(hooks))
+	ordered := make([]Hook, 0, len(hooks))
+
+	var visit func(h Hook) error
+	visit = func(h Hook) error {
+		switch mark[h.Name] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("lifecycle: dependency cycle at %s", h.Name)
+		}
+		mark[h.Name] = visiting
+		for _, d := range h.DependsOn {
+			dep, ok := byName[d]
+			if !ok {
+				return fmt.Errorf("lifecycle: %s depends on unknown %s", h.Name, d)
+			}
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		mark[h.Name] = visited
+		ordered = append(ordered, h)
+		return nil
+	}
+
+	for _, h := range hooks {
+		if err := visit(h); err != nil {
+			return nil, err
+		}
+	}
+	return ordered, nil
+}
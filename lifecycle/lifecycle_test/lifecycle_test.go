@@ -0,0 +1,144 @@
+package lifecycle_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"web/lifecycle"
+	"web/logger"
+
+	"go.uber.org/zap"
+)
+
+func init() {
+	logger.ErrorLogger = zap.NewNop().Sugar()
+}
+
+func hook(name string, log *[]string, deps ...string) lifecycle.Hook {
+	return lifecycle.Hook{
+		Name:      name,
+		DependsOn: deps,
+		Start: func(context.Context) error {
+			*log = append(*log, "start "+name)
+			return nil
+		},
+		Stop: func(context.Context) error {
+			*log = append(*log, "stop "+name)
+			return nil
+		},
+	}
+}
+
+func TestOrder(t *testing.T) {
+	var log []string
+	lc := lifecycle.New()
+	for _, h := range []lifecycle.Hook{
+		hook("http", &log, "db", "cache"),
+		hook("db", &log),
+		hook("cache", &log, "db"),
+	} {
+		if err := lc.Append(h); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := lc.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := lc.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"start db", "start cache", "start http", "stop http", "stop cache", "stop db"}
+	if !reflect.DeepEqual(log, want) {
+		t.Fatalf("got %v want %v", log, want)
+	}
+}
+
+func TestDependency(t *testing.T) {
+	var log []string
+	lc := lifecycle.New()
+	_ = lc.Append(hook("a", &log, "b"))
+	_ = lc.Append(hook("b", &log, "a"))
+	if err := lc.Start(context.Background()); err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Fatalf("err %v", err)
+	}
+
+	lc = lifecycle.New()
+	_ = lc.Append(hook("a", &log, "missing"))
+	if err := lc.Start(context.Background()); err == nil {
+		t.Fatal("unknown dependency accepted")
+	}
+
+	if err := lc.Append(hook("a", &log)); err == nil {
+		t.Fatal("duplicate accepted")
+	}
+}
+
+func TestStartFailed(t *testing.T) {
+	var log []string
+	lc := lifecycle.New()
+	_ = lc.Append(hook("db", &log))
+	_ = lc.Append(lifecycle.Hook{
+		Name:  "http",
+		Start: func(context.Context) error { panic("listen") },
+		Stop: func(context.Context) error {
+			log = append(log, "stop http")
+			return nil
+		},
+	})
+
+	if err := lc.Start(context.Background()); err == nil || !strings.Contains(err.Error(), "start http") {
+		t.Fatalf("err %v", err)
+	}
+	// 只停止已启动的组件
+	if err := lc.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"start db", "stop db"}
+	if !reflect.DeepEqual(log, want) {
+		t.Fatalf("got %v want %v", log, want)
+	}
+}
+
+func TestStopTimeout(t *testing.T) {
+	var log []string
+	block := make(chan struct{})
+	defer close(block)
+
+	lc := lifecycle.New()
+	_ = lc.Append(hook("db", &log))
+	_ = lc.Append(lifecycle.Hook{
+		Name: "jobs",
+		Stop: func(context.Context) error {
+			<-block
+			return nil
+		},
+	})
+	if err := lc.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := lc.Stop(ctx)
+	if !errors.Is(err, lifecycle.ErrStopTimeout) {
+		t.Fatalf("err %v", err)
+	}
+	for _, name := range []string{"stop jobs", "stop db"} {
+		if !strings.Contains(err.Error(), name) {
+			t.Fatalf("%s not reported: %v", name, err)
+		}
+	}
+}
+
+func TestFail(t *testing.T) {
+	lc := lifecycle.New()
+	lc.Fail(errors.New("first"))
+	lc.Fail(errors.New("second"))
+	if err := <-lc.Failed(); err.Error() != "first" {
+		t.Fatalf("err %v", err)
+	}
+}
@@ -2,25 +2,34 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 	"web/config"
+	"web/constant"
+	"web/dao"
 	"web/jobs"
+	"web/lifecycle"
 	"web/logger"
+	"web/repository/blocksource"
 	"web/repository/cache"
+	"web/repository/leader"
+	"web/repository/merklefetch"
+	"web/repository/merklestore"
 	"web/repository/pg"
+	"web/repository/state"
 	"web/utils"
 	"web/web/router"
-
-	"github.com/pkg/errors"
-	"go.uber.org/zap"
 )
 
+const defaultShutDownTimeout = 30 * time.Second
+
 func main() {
 	// flag
 	path := flag.String("config", "./conf.json", "config path")
@@ -32,74 +41,257 @@ func main() {
 	// init validator config
 	config.InitConfig(*path)
 
-	// main context
-	mainCtx, cancel := context.WithCancel(context.TODO())
-
-	// init db
-	pg.InitPg(config.Configure)
+	os.Exit(run())
+}
 
-	// init cache
-	cache.InitMMCache()
+// run 启动所有组件，收到退出信号或组件失败后逆序关闭，返回进程退出码
+func run() int {
+	lc := lifecycle.New()
+	for _, h := range hooks(lc) {
+		if err := lc.Append(h); err != nil {
+			logger.Errorf("register component failed. [err:%v]", err)
+			return 1
+		}
+	}
 
-	// init utils
-	utils.InitUtils()
+	code := 0
+	if err := lc.Start(context.Background()); err != nil {
+		logger.Errorf("start failed, shutdown now. [err:%v]", err)
+		code = 1
+	} else {
+		quit := make(chan os.Signal, 1)
+		signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 
-	// run web server
-	go func() {
-		err := runHttpServer(mainCtx)
-		if err != nil {
-			logger.Error("http server run got err", zap.Error(err))
+		select {
+		case sg := <-quit:
+			logger.Infof("Receive signal %v and shutdown...", sg)
+		case err := <-lc.Failed():
+			logger.Errorf("component failed, shutdown now. [err:%v]", err)
+			code = 1
 		}
-		panic(err)
-	}()
+	}
 
-	// run job
-	go jobs.RunJob(mainCtx)
+	timeout := config.Configure.ServerSetting.ShutDownTimeout * time.Second
+	if timeout <= 0 {
+		timeout = defaultShutDownTimeout
+	}
+	logger.Infof("shutdown within %+v", timeout)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := lc.Stop(ctx); err != nil {
+		logger.Errorf("shutdown not clean. [err:%v]", err)
+		code = 1
+	}
+	return code
+}
 
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+// hooks 组件的启动与关闭，关闭顺序与启动相反
+func hooks(lc *lifecycle.Manager) []lifecycle.Hook {
+	return []lifecycle.Hook{
+		{
+			Name: "logger",
+			Stop: func(ctx context.Context) error {
+				// stdout 不支持 sync，忽略错误
+				_ = logger.ErrorLogger.Sync()
+				return nil
+			},
+		},
+		{
+			Name: "pg",
+			Start: func(ctx context.Context) error {
+				pg.InitPg(config.Configure)
+				if db, err := pg.GetDB(constant.DBNameMain); err == nil {
+					return dao.AutoMigrate(db)
+				}
+				return nil
+			},
+			Stop: func(ctx context.Context) error {
+				return pg.Close()
+			},
+		},
+		{
+			Name: "cache",
+			Start: func(ctx context.Context) error {
+				cache.InitMMCache()
+				return nil
+			},
+			Stop: func(ctx context.Context) error {
+				cache.GetCache().Flush()
+				return nil
+			},
+		},
+		{
+			Name: "merklestore",
+			Start: func(ctx context.Context) error {
+				merklestore.InitMerkleStore(config.Configure)
+				return nil
+			},
+			Stop: func(ctx context.Context) error {
+				return merklestore.Close()
+			},
+		},
+		{
+			Name:      "state",
+			DependsOn: []string{"merklestore"},
+			Start: func(ctx context.Context) error {
+				state.InitState(config.Configure)
+				return nil
+			},
+		},
+		{
+			Name:      "merklefetch",
+			DependsOn: []string{"merklestore", "state"},
+			Start: func(ctx context.Context) error {
+				merklefetch.InitFetcher(config.Configure)
+				return nil
+			},
+		},
+		{
+			Name: "blocksource",
+			Start: func(ctx context.Context) error {
+				blocksource.InitBlockSource(config.Configure)
+				return nil
+			},
+		},
+		{
+			Name:      "utils",
+			DependsOn: []string{"pg"},
+			Start: func(ctx context.Context) error {
+				return utils.InitUtils(ctx, config.Configure)
+			},
+			Stop: func(ctx context.Context) error {
+				return utils.CloseUtils(ctx)
+			},
+		},
+		leaderHook(),
+		jobsHook(),
+		httpHook(lc),
+	}
+}
 
-	sg := <-quit
-	logger.Infof("Receive signal %v and shutdown...", sg)
+// leaderHook 在 jobs 之后关闭，leader 任务停止后才释放锁
+func leaderHook() lifecycle.Hook {
+	var (
+		cancel context.CancelFunc
+		done   = make(chan struct{})
+	)
+	return lifecycle.Hook{
+		Name:      "leader",
+		DependsOn: []string{"pg"},
+		Start: func(context.Context) error {
+			if err := leader.InitLeader(config.Configure); err != nil {
+				return err
+			}
+			l := leader.GetLeader()
+			if l == nil {
+				close(done)
+				return nil
+			}
 
-	cancel()
-	
-	logger.Infof("delay cancel in %+v ", config.Configure.ServerSetting.ShutDownTimeout)
-	time.Sleep(config.Configure.ServerSetting.ShutDownTimeout)
+			var ctx context.Context
+			ctx, cancel = context.WithCancel(context.Background())
+			go func() {
+				defer close(done)
+				l.Run(ctx)
+			}()
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			if cancel != nil {
+				cancel()
+			}
+			select {
+			case <-done:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	}
+}
+
+// jobsHook 关闭时取消任务并等待全部退出
+func jobsHook() lifecycle.Hook {
+	var (
+		cancel context.CancelFunc
+		done   = make(chan struct{})
+	)
+	return lifecycle.Hook{
+		Name:      "jobs",
+		DependsOn: []string{"pg", "merklestore", "state", "merklefetch", "blocksource", "leader"},
+		Start: func(context.Context) error {
+			if err := jobs.InitScheduler(config.Configure); err != nil {
+				return err
+			}
+
+			var ctx context.Context
+			ctx, cancel = context.WithCancel(context.Background())
+			go func() {
+				defer close(done)
+				jobs.RunJob(ctx)
+			}()
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			cancel()
+			select {
+			case <-done:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	}
+}
+
+// httpHook 监听失败在启动时返回，运行中出错通过 lc.Fail 触发关闭
+func httpHook(lc *lifecycle.Manager) lifecycle.Hook {
+	var (
+		svr    *http.Server
+		cancel context.CancelFunc
+	)
+	return lifecycle.Hook{
+		Name:      "http",
+		DependsOn: []string{"pg", "cache", "merklestore", "state", "merklefetch", "utils", "jobs"},
+		Start: func(context.Context) error {
+			var ctx context.Context
+			// 请求 context 从 ctx 派生，关闭超时后取消进行中的请求与 SQL
+			ctx, cancel = context.WithCancel(context.Background())
+			svr = newHttpServer(ctx)
+
+			ln, err := net.Listen("tcp", svr.Addr)
+			if err != nil {
+				return err
+			}
+			go func() {
+				logger.Infof("Start http server listening %s", svr.Addr)
+				if err := svr.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+					lc.Fail(fmt.Errorf("http server serve: %w", err))
+				}
+			}()
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			defer cancel()
+			logger.Warnf("stop http server %s", svr.Addr)
+			return svr.Shutdown(ctx)
+		},
+	}
 }
 
-func runHttpServer(ctx context.Context) error {
-	routersInit := router.InitRouter()
+func newHttpServer(ctx context.Context) *http.Server {
 	readTimeout := config.Configure.ServerSetting.ReadTimeout * time.Second
 	writeTimeout := config.Configure.ServerSetting.WriteTimeout * time.Second
 	endPoint := fmt.Sprintf(":%d", config.Configure.ServerSetting.HttpPort)
 	maxHeaderBytes := 1 << 20
 
-	svr := &http.Server{
+	return &http.Server{
 		Addr:           endPoint,
 		ReadTimeout:    readTimeout,
 		WriteTimeout:   writeTimeout,
-		Handler:        routersInit,
+		Handler:        router.InitRouter(),
 		MaxHeaderBytes: maxHeaderBytes,
-	}
-
-	stop := make(chan error)
-	go func() {
-		logger.Infof("Start http server listening %s", endPoint)
-		if err := svr.ListenAndServe(); err == nil || err == http.ErrServerClosed {
-			stop <- nil
-		} else {
-			stop <- errors.Wrap(err, "server serve err")
-		}
-	}()
-
-	select {
-	case <-ctx.Done():
-		logger.Warnf("Canceled, stop server %s", endPoint)
-		c, cancel := context.WithTimeout(context.TODO(), config.Configure.ServerSetting.ShutDownTimeout)
-		defer cancel()
-		return errors.Wrap(svr.Shutdown(c), "server shutdown err")
-	case err := <-stop:
-		return err
+		BaseContext:    func(net.Listener) context.Context { return ctx },
 	}
 }
@@ -0,0 +1,41 @@
+package blocksource
+
+import (
+	"fmt"
+	"time"
+	"web/config"
+	"web/repository/chain"
+)
+
+const (
+	TypeRPC    = "rpc"
+	TypeReplay = "replay"
+)
+
+var source BlockSource
+
+// InitBlockSource 按配置创建区块数据来源，未配置时不拉取
+// 同时作为链重组检测的区块哈希来源
+func InitBlockSource(config config.Configuration) {
+	cfg := config.BlockSource
+	switch cfg.Type {
+	case "":
+		return
+	case TypeRPC:
+		source = NewRPCSource(cfg.RpcUrl, cfg.RpcUser, cfg.RpcPassword, cfg.OrdUrl, cfg.Timeout*time.Second)
+	case TypeReplay:
+		s, err := NewReplaySource(cfg.ReplayPath)
+		if err != nil {
+			panic(err)
+		}
+		source = s
+	default:
+		panic(fmt.Sprintf("unknown block source type %q", cfg.Type))
+	}
+
+	chain.SetHashSource(source.BlockHash)
+}
+
+func GetSource() BlockSource {
+	return source
+}
@@ -0,0 +1,165 @@
+package blocksource_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"web/constant"
+	"web/repository/blocksource"
+)
+
+func TestReplaySource(t *testing.T) {
+	dir := t.TempDir()
+	_ = os.WriteFile(filepath.Join(dir, "1.jsonl"), []byte(
+		`{"height":1,"hash":"a1","prev_hash":"a0","events":[{"op":"deploy","tick":"ordi","max":"1000"}]}
+{"height":2,"hash":"a2","prev_hash":"a1"}
+`), 0o644)
+	// 后面的文件覆盖同一高度
+	_ = os.WriteFile(filepath.Join(dir, "2.jsonl"), []byte(`{"height":2,"hash":"b2","prev_hash":"a1"}`), 0o644)
+
+	s, err := blocksource.NewReplaySource(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if h, _ := s.Height(ctx); h != 2 {
+		t.Fatalf("unexpected height %d", h)
+	}
+	if hash, _ := s.BlockHash(ctx, 2); hash != "b2" {
+		t.Fatalf("unexpected hash %s", hash)
+	}
+	b, err := s.Block(ctx, 1)
+	if err != nil || len(b.Events) != 1 || b.Events[0].Tick != "ordi" {
+		t.Fatalf("unexpected block %+v (%v)", b, err)
+	}
+	if _, err = s.Block(ctx, 3); !errors.Is(err, blocksource.ErrNotExist) {
+		t.Fatalf("expected ErrNotExist, got %v", err)
+	}
+}
+
+// node 模拟 Bitcoin Core JSON-RPC 与 ord JSON API
+func node(t *testing.T) (rpc, ord *httptest.Server) {
+	blocks := map[string]string{
+		"h1": `{"hash":"h1","previousblockhash":"h0","tx":[
+			{"txid":"aa","vin":[],"vout":[]},{"txid":"bb","vin":[],"vout":[]},{"txid":"cc","vin":[],"vout":[]},
+			{"txid":"dd","vin":[],"vout":[]},{"txid":"ee","vin":[],"vout":[]},{"txid":"ff","vin":[],"vout":[]}]}`,
+		"h2": `{"hash":"h2","previousblockhash":"h1","tx":[{"txid":"gg",
+			"vin":[{"txid":"x","vout":0,"prevout":{"value":0.0002}},{"txid":"cc","vout":0,"prevout":{"value":0.0001}}],
+			"vout":[{"value":0.0002,"scriptPubKey":{"address":"A"}},{"value":0.0001,"scriptPubKey":{"address":"B"}}]}]}`,
+	}
+	rpc = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, _ := r.BasicAuth(); user != "u" || pass != "p" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var req struct {
+			Method string `json:"method"`
+			Params []any  `json:"params"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+
+		var result any
+		switch req.Method {
+		case "getblockcount":
+			result = 2
+		case "getblockhash":
+			h := req.Params[0].(float64)
+			if h > 2 {
+				w.WriteHeader(http.StatusInternalServerError)
+				_, _ = w.Write([]byte(`{"result":null,"error":{"code":-8,"message":"Block height out of range"}}`))
+				return
+			}
+			result = fmt.Sprintf("h%d", int(h))
+		case "getblock":
+			result = json.RawMessage(blocks[req.Params[0].(string)])
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"result": result, "error": nil})
+	}))
+
+	type ins struct {
+		contentType, content, satpoint string
+	}
+	inscriptions := map[string]ins{
+		"aai0": {"text/plain;charset=utf-8", `{"p":"brc-20","op":"deploy","tick":"ordi","max":"1000"}`, "aa:0:0"},
+		"bbi0": {"text/plain", `{"p":"brc-20","op":"mint","tick":"ordi","amt":"100"}`, "bb:0:0"},
+		"cci0": {"application/json", `{"p":"brc-20","op":"transfer","tick":"ordi","amt":"30"}`, "cc:0:0"},
+		"ddi0": {"image/png", "png", "dd:0:0"},
+		"eei0": {"text/plain", "hello", "ee:0:0"},
+		"ffi0": {"text/plain", `{"p":"brc-20","op":"mint","tick":"ordi","amt":1}`, "ff:0:0"},
+	}
+	ord = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/"), "/")
+		switch parts[0] {
+		case "block":
+			if parts[1] == "h1" {
+				_, _ = w.Write([]byte(`{"inscriptions":["aai0","bbi0","cci0","ddi0","eei0","ffi0"]}`))
+			} else {
+				_, _ = w.Write([]byte(`{"inscriptions":[]}`))
+			}
+		case "inscription":
+			i, ok := inscriptions[parts[1]]
+			if !ok || r.Header.Get("Accept") != "application/json" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"address": "a", "number": 1, "content_type": i.contentType, "satpoint": i.satpoint,
+			})
+		case "content":
+			_, _ = w.Write([]byte(inscriptions[parts[1]].content))
+		}
+	}))
+	t.Cleanup(rpc.Close)
+	t.Cleanup(ord.Close)
+	return rpc, ord
+}
+
+func TestRPCSource(t *testing.T) {
+	rpc, ord := node(t)
+	s := blocksource.NewRPCSource(rpc.URL, "u", "p", ord.URL, 0)
+	s.SetPending(func() []string { return []string{"cci0"} })
+	ctx := context.Background()
+
+	if h, err := s.Height(ctx); err != nil || h != 2 {
+		t.Fatalf("unexpected height %d (%v)", h, err)
+	}
+	b, err := s.Block(ctx, 1)
+	if err != nil || b.Hash != "h1" || b.PrevHash != "h0" {
+		t.Fatalf("unexpected block %+v (%v)", b, err)
+	}
+	// 图片与非 BRC-20 文本被跳过，格式有误的 BRC-20 铭文保留给 Engine 记录错误码
+	var ids []string
+	for _, ev := range b.Events {
+		ids = append(ids, ev.InscriptionID)
+	}
+	if strings.Join(ids, ",") != "aai0,bbi0,cci0,ffi0" || b.Events[0].Content == "" || b.Events[0].To != "a" {
+		t.Fatalf("unexpected events %+v", b.Events)
+	}
+
+	// transfer 铭文所在的输出被花费，铭文按先进先出落在第二个输出
+	b, err = s.Block(ctx, 2)
+	if err != nil || len(b.Events) != 1 {
+		t.Fatalf("unexpected block %+v (%v)", b, err)
+	}
+	if ev := b.Events[0]; ev.Op != constant.BRC20_OP_SEND || ev.InscriptionID != "cci0" || ev.To != "B" {
+		t.Fatalf("unexpected send %+v", ev)
+	}
+
+	// 重启后位置从 ord 查询
+	s = blocksource.NewRPCSource(rpc.URL, "u", "p", ord.URL, 0)
+	s.SetPending(func() []string { return []string{"cci0"} })
+	if b, err = s.Block(ctx, 2); err != nil || len(b.Events) != 1 || b.Events[0].To != "B" {
+		t.Fatalf("unexpected block after restart %+v (%v)", b, err)
+	}
+
+	if _, err = s.Block(ctx, 3); !errors.Is(err, blocksource.ErrNotExist) {
+		t.Fatalf("expected ErrNotExist, got %v", err)
+	}
+}
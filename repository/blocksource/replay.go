@@ -0,0 +1,81 @@
+package blocksource
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// ReplaySource 从 JSONL 文件回放区块，每行是一个 Block
+// 同一高度出现多次时以后出现的为准，可以用来模拟链重组
+type ReplaySource struct {
+	blocks map[uint]Block
+	tip    uint
+}
+
+// NewReplaySource path 可以是单个文件，也可以是目录（按文件名顺序读取其中的 *.jsonl）
+func NewReplaySource(path string) (*ReplaySource, error) {
+	files := []string{path}
+	if info, err := os.Stat(path); err != nil {
+		return nil, err
+	} else if info.IsDir() {
+		if files, err = filepath.Glob(filepath.Join(path, "*.jsonl")); err != nil {
+			return nil, err
+		}
+		sort.Strings(files)
+	}
+
+	s := &ReplaySource{blocks: make(map[uint]Block)}
+	for _, f := range files {
+		if err := s.load(f); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func (s *ReplaySource) load(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var b Block
+		if err = json.Unmarshal(scanner.Bytes(), &b); err != nil {
+			return fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		s.blocks[b.Height] = b
+		if b.Height > s.tip {
+			s.tip = b.Height
+		}
+	}
+	return scanner.Err()
+}
+
+func (s *ReplaySource) Height(ctx context.Context) (uint, error) {
+	return s.tip, nil
+}
+
+func (s *ReplaySource) BlockHash(ctx context.Context, height uint) (string, error) {
+	b, err := s.Block(ctx, height)
+	return b.Hash, err
+}
+
+func (s *ReplaySource) Block(ctx context.Context, height uint) (Block, error) {
+	b, ok := s.blocks[height]
+	if !ok {
+		return b, ErrNotExist
+	}
+	return b, nil
+}
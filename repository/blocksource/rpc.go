@@ -0,0 +1,432 @@
+package blocksource
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"web/brc20"
+	"web/constant"
+)
+
+/*
+	rpc 区块与交易来自 Bitcoin Core JSON-RPC，铭文来自 ord 的 JSON API
+	1. getblockcount、getblockhash 返回主链高度与哈希，getblock（verbosity 3）返回区块内的交易及其输入金额
+	2. 新铭文：ord /block/<hash> 列出区块内的铭文，/inscription/<id> 返回编号、地址、父铭文与位置，
+	   /content/<id> 返回原始内容，由 brc20.ParseInscription 判断是否为 BRC-20 铭文
+	3. send：transfer 铭文所在的输出被花费时，按序数理论（先进先出）计算铭文落在哪个输出，
+	   落在手续费里时接收方为空。铭文位置按 ord 当前的 satpoint 确定，已经移动过时使用揭示交易的第一个输出
+*/
+
+const (
+	defaultRPCTimeout = 30 * time.Second
+	// Bitcoin Core 区块不存在时的错误码
+	rpcErrInvalidParameter = -8
+	rpcErrBlockNotFound    = -5
+	// getblock 返回交易以及输入的 prevout
+	blockVerbosity = 3
+	satsPerBTC     = 1e8
+)
+
+// 不是 BRC-20 铭文的解析错误，这些铭文不产生事件
+var notBrc20 = map[string]bool{
+	brc20.ErrCodeJSON:      true,
+	brc20.ErrCodeNotObject: true,
+	brc20.ErrCodeProtocol:  true,
+}
+
+// RPCSource Bitcoin Core JSON-RPC 加 ord JSON API
+type RPCSource struct {
+	url      string
+	user     string
+	password string
+	ordURL   string
+	client   *http.Client
+
+	id atomic.Uint64
+
+	mu sync.Mutex
+	// pending 返回尚未被 send 的 transfer 铭文
+	pending func() []string
+	// transfer 铭文所在的位置
+	locations map[string]satpoint
+}
+
+type rpcRequest struct {
+	JSONRPC string `json:"jsonrpc"`
+	ID      uint64 `json:"id"`
+	Method  string `json:"method"`
+	Params  []any  `json:"params"`
+}
+
+type rpcResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *RPCError       `json:"error"`
+}
+
+// RPCError 接口返回的错误
+type RPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("rpc error %d: %s", e.Code, e.Message)
+}
+
+type rpcBlock struct {
+	Hash              string  `json:"hash"`
+	PreviousBlockHash string  `json:"previousblockhash"`
+	Tx                []rpcTx `json:"tx"`
+}
+
+type rpcTx struct {
+	Txid string `json:"txid"`
+	Vin  []struct {
+		Txid    string `json:"txid"`
+		Vout    uint32 `json:"vout"`
+		Prevout *struct {
+			Value json.Number `json:"value"`
+		} `json:"prevout"`
+	} `json:"vin"`
+	Vout []struct {
+		Value        json.Number `json:"value"`
+		ScriptPubKey struct {
+			Address string `json:"address"`
+		} `json:"scriptPubKey"`
+	} `json:"vout"`
+}
+
+type ordBlock struct {
+	Inscriptions []string `json:"inscriptions"`
+}
+
+type ordInscription struct {
+	Address     string   `json:"address"`
+	Number      int64    `json:"number"`
+	ContentType string   `json:"content_type"`
+	Parent      string   `json:"parent"`
+	Parents     []string `json:"parents"`
+	Satpoint    string   `json:"satpoint"`
+}
+
+// satpoint 铭文所在的输出以及在输出内的偏移
+type satpoint struct {
+	txid   string
+	vout   uint32
+	offset uint64
+}
+
+// NewRPCSource ordURL 为 ord 服务地址，timeout 为 0 时使用 30 秒
+func NewRPCSource(url, user, password, ordURL string, timeout time.Duration) *RPCSource {
+	if timeout <= 0 {
+		timeout = defaultRPCTimeout
+	}
+	return &RPCSource{
+		url:       url,
+		user:      user,
+		password:  password,
+		ordURL:    strings.TrimRight(ordURL, "/"),
+		client:    &http.Client{Timeout: timeout},
+		locations: make(map[string]satpoint),
+	}
+}
+
+// SetPending 实现 PendingTracker
+func (s *RPCSource) SetPending(pending func() []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pending = pending
+}
+
+func (s *RPCSource) Height(ctx context.Context) (uint, error) {
+	var height uint
+	err := s.call(ctx, "getblockcount", &height)
+	return height, err
+}
+
+func (s *RPCSource) BlockHash(ctx context.Context, height uint) (string, error) {
+	var hash string
+	err := s.call(ctx, "getblockhash", &hash, height)
+	return hash, err
+}
+
+// Block 同一笔交易中先处理 send，再处理新铭文
+func (s *RPCSource) Block(ctx context.Context, height uint) (Block, error) {
+	ret := Block{Height: height}
+
+	hash, err := s.BlockHash(ctx, height)
+	if err != nil {
+		return ret, err
+	}
+	var block rpcBlock
+	if err = s.call(ctx, "getblock", &block, hash, blockVerbosity); err != nil {
+		return ret, err
+	}
+	var ob ordBlock
+	if err = s.ord(ctx, "/block/"+hash, &ob); err != nil {
+		return ret, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	txIndex := make(map[string]int, len(block.Tx))
+	for i, tx := range block.Tx {
+		txIndex[tx.Txid] = i
+	}
+
+	events, err := s.sends(ctx, block)
+	if err != nil {
+		return ret, err
+	}
+
+	for _, id := range ob.Inscriptions {
+		ev, ok, err := s.inscription(ctx, id)
+		if err != nil {
+			return ret, err
+		}
+		if !ok {
+			continue
+		}
+		tx, found := txIndex[revealTxid(id)]
+		if !found {
+			tx = len(block.Tx)
+		}
+		events = append(events, txEvent{tx: tx, ev: ev})
+	}
+	// send 在前，同一类按原有顺序
+	sort.SliceStable(events, func(i, j int) bool { return events[i].tx < events[j].tx })
+
+	ret.Hash = block.Hash
+	ret.PrevHash = block.PreviousBlockHash
+	ret.Events = make([]brc20.Event, 0, len(events))
+	for _, e := range events {
+		ret.Events = append(ret.Events, e.ev)
+	}
+	return ret, nil
+}
+
+// inscription 读取铭文内容，不是 BRC-20 铭文时 ok 为 false
+// 格式有误但声明为 BRC-20 的铭文仍然返回，由 Engine 记录解析错误码
+func (s *RPCSource) inscription(ctx context.Context, id string) (brc20.Event, bool, error) {
+	var ev brc20.Event
+
+	var info ordInscription
+	if err := s.ord(ctx, "/inscription/"+id, &info); err != nil {
+		return ev, false, err
+	}
+	// BRC-20 只认 text/plain 与 application/json
+	if !strings.HasPrefix(info.ContentType, "text/plain") && !strings.HasPrefix(info.ContentType, "application/json") {
+		return ev, false, nil
+	}
+	content, err := s.ordRaw(ctx, "/content/"+id)
+	if err != nil {
+		return ev, false, err
+	}
+	ins, err := brc20.ParseInscription(content)
+	var pe *brc20.ParseError
+	if errors.As(err, &pe) && notBrc20[pe.Code] {
+		return ev, false, nil
+	}
+
+	ev = brc20.Event{
+		InscriptionID:     id,
+		InscriptionNumber: info.Number,
+		To:                info.Address,
+		Content:           string(content),
+		Parent:            info.Parent,
+	}
+	if len(info.Parents) > 0 {
+		ev.Parent = info.Parents[0]
+	}
+	if err == nil && ins.OpCode == constant.BRC20_OP_N_TRANSFER {
+		s.locations[id] = genesisLocation(id, info.Satpoint)
+	}
+	return ev, true, nil
+}
+
+// txEvent 事件以及所在交易在区块内的序号
+type txEvent struct {
+	tx int
+	ev brc20.Event
+}
+
+// sends 在区块交易中查找花费了 transfer 铭文所在输出的输入
+func (s *RPCSource) sends(ctx context.Context, block rpcBlock) ([]txEvent, error) {
+	if s.pending == nil {
+		return nil, nil
+	}
+	pending := s.pending()
+	if len(pending) == 0 {
+		return nil, nil
+	}
+
+	watch := make(map[string][]string, len(pending))
+	for _, id := range pending {
+		loc, ok := s.locations[id]
+		if !ok {
+			// 重启后的第一次使用，从 ord 查询
+			var info ordInscription
+			if err := s.ord(ctx, "/inscription/"+id, &info); err != nil {
+				return nil, err
+			}
+			loc = genesisLocation(id, info.Satpoint)
+			s.locations[id] = loc
+		}
+		key := outpoint(loc.txid, loc.vout)
+		watch[key] = append(watch[key], id)
+	}
+
+	var ret []txEvent
+	for i, tx := range block.Tx {
+		var inOffset uint64
+		for _, in := range tx.Vin {
+			ids := watch[outpoint(in.Txid, in.Vout)]
+			for _, id := range ids {
+				ev := brc20.Event{Op: constant.BRC20_OP_SEND, InscriptionID: id}
+				ev.To = receiver(tx, inOffset+s.locations[id].offset)
+				ret = append(ret, txEvent{tx: i, ev: ev})
+				delete(s.locations, id)
+			}
+			if in.Prevout != nil {
+				inOffset += sats(in.Prevout.Value)
+			}
+		}
+	}
+	return ret, nil
+}
+
+// receiver 第 offset 个输入 sat 所在输出的地址，落在手续费中时为空
+func receiver(tx rpcTx, offset uint64) string {
+	var end uint64
+	for _, out := range tx.Vout {
+		end += sats(out.Value)
+		if offset < end {
+			return out.ScriptPubKey.Address
+		}
+	}
+	return ""
+}
+
+// genesisLocation 铭文还在揭示交易里时使用 ord 返回的 satpoint，否则使用揭示交易的第一个输出
+func genesisLocation(id, sp string) satpoint {
+	reveal := revealTxid(id)
+	parts := strings.Split(sp, ":")
+	if len(parts) == 3 && parts[0] == reveal {
+		vout, err1 := strconv.ParseUint(parts[1], 10, 32)
+		offset, err2 := strconv.ParseUint(parts[2], 10, 64)
+		if err1 == nil && err2 == nil {
+			return satpoint{txid: reveal, vout: uint32(vout), offset: offset}
+		}
+	}
+	return satpoint{txid: reveal}
+}
+
+// revealTxid 铭文 ID 为 <txid>i<index>
+func revealTxid(id string) string {
+	if i := strings.LastIndexByte(id, 'i'); i > 0 {
+		return id[:i]
+	}
+	return id
+}
+
+func outpoint(txid string, vout uint32) string {
+	return fmt.Sprintf("%s:%d", txid, vout)
+}
+
+func sats(btc json.Number) uint64 {
+	v, _ := btc.Float64()
+	return uint64(math.Round(v * satsPerBTC))
+}
+
+func (s *RPCSource) ord(ctx context.Context, path string, result any) error {
+	b, err := s.ordRaw(ctx, path, "application/json")
+	if err != nil {
+		return err
+	}
+	if err = json.Unmarshal(b, result); err != nil {
+		return fmt.Errorf("ord %s: %w", path, err)
+	}
+	return nil
+}
+
+func (s *RPCSource) ordRaw(ctx context.Context, path string, accept ...string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.ordURL+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(accept) > 0 {
+		req.Header.Set("Accept", accept[0])
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, fmt.Errorf("ord %s: %w", path, ErrNotExist)
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("ord %s: unexpected status %d", path, resp.StatusCode)
+	}
+	return b, nil
+}
+
+func (s *RPCSource) call(ctx context.Context, method string, result any, params ...any) error {
+	if params == nil {
+		params = []any{}
+	}
+	body, err := json.Marshal(rpcRequest{JSONRPC: "1.0", ID: s.id.Add(1), Method: method, Params: params})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.user != "" || s.password != "" {
+		req.SetBasicAuth(s.user, s.password)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	// Bitcoin Core 出错时 HTTP 状态码不是 200，但响应体里仍有 error
+	var ret rpcResponse
+	if err = json.Unmarshal(b, &ret); err != nil {
+		return fmt.Errorf("%s: unexpected response %d: %w", method, resp.StatusCode, err)
+	}
+	if ret.Error != nil {
+		if ret.Error.Code == rpcErrInvalidParameter || ret.Error.Code == rpcErrBlockNotFound {
+			return fmt.Errorf("%s: %w: %v", method, ErrNotExist, ret.Error)
+		}
+		return fmt.Errorf("%s: %w", method, ret.Error)
+	}
+	return json.Unmarshal(ret.Result, result)
+}
@@ -0,0 +1,38 @@
+package blocksource
+
+import (
+	"context"
+	"errors"
+	"web/brc20"
+)
+
+/*
+	blocksource 主动拉取区块数据，替代等待 PUT /api/merkle/build 推送
+	1. rpc：Bitcoin Core JSON-RPC 加 ord JSON API，铭文内容由 brc20.ParseInscription 解析
+	2. replay：从磁盘读取录制好的 JSONL 文件，用于离线测试
+*/
+
+var ErrNotExist = errors.New("block not exist")
+
+// Block 单个区块的数据，Events 按区块内的处理顺序排列
+type Block struct {
+	Height   uint          `json:"height"`
+	Hash     string        `json:"hash"`
+	PrevHash string        `json:"prev_hash"`
+	Events   []brc20.Event `json:"events"`
+}
+
+// BlockSource 区块数据来源
+type BlockSource interface {
+	// Height 返回当前主链的最高区块
+	Height(ctx context.Context) (uint, error)
+	// BlockHash 返回主链上指定高度的区块哈希
+	BlockHash(ctx context.Context, height uint) (string, error)
+	// Block 返回主链上指定高度的区块以及其中的铭文事件
+	Block(ctx context.Context, height uint) (Block, error)
+}
+
+// PendingTracker 需要知道尚未被 send 的 transfer 铭文才能生成 send 事件的来源
+type PendingTracker interface {
+	SetPending(pending func() []string)
+}
@@ -0,0 +1,196 @@
+package chain
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"web/constant"
+	"web/dao"
+	"web/logger"
+	"web/repository/merklestore"
+	"web/repository/pg"
+	"web/repository/state"
+)
+
+/*
+	chain 检测链重组并回退本地数据
+	1. 每个区块的 Merkle 文件中保存区块哈希与父区块哈希
+	2. 新区块的父哈希与本地不一致，或者同一高度的哈希变化时认为发生了重组
+	3. 从分叉点之后的本地数据全部作废：本地 Merkle 文件、BRC-20 状态、对账结果以及本地运行状态，
+	   远端镜像不属于本地链，保持不变
+	4. 检查与写入在同一把锁内（Commit），避免推送与拉取交替写入
+*/
+
+// HashSource 返回当前主链上某个高度的区块哈希
+type HashSource func(ctx context.Context, height uint) (string, error)
+
+var (
+	mu     sync.Mutex
+	source HashSource
+)
+
+// SetHashSource 设置主链区块哈希来源，设置后检测到重组时可以直接找到分叉点
+func SetHashSource(s HashSource) {
+	mu.Lock()
+	defer mu.Unlock()
+	source = s
+}
+
+// Reorg 一次链重组，Fork 为两条链最后一个相同的区块
+type Reorg struct {
+	Fork uint
+	// 回退前本地最高的区块
+	Tip uint
+	// 区块 0 也不在主链上，本地数据全部回退，此时 Fork 无意义
+	All bool
+}
+
+// Depth 被回退的区块数
+func (r Reorg) Depth() uint {
+	if r.All {
+		return r.Tip + 1
+	}
+	if r.Tip < r.Fork {
+		return 0
+	}
+	return r.Tip - r.Fork
+}
+
+// Next 回退后需要重新处理的第一个区块
+func (r Reorg) Next() uint {
+	if r.All {
+		return 0
+	}
+	return r.Fork + 1
+}
+
+// Check 检查即将写入的区块是否与本地链衔接，发生重组时回退本地数据
+// 返回 nil 表示没有重组；回退后 block-1 不存在时调用方需要从 Next() 重新处理
+func Check(ctx context.Context, block uint, hash, prevHash string) (*Reorg, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	return check(ctx, block, hash, prevHash)
+}
+
+// Commit 在同一把锁内检查重组并写入区块
+// 没有重组，或者回退后区块正好接在分叉点之后时调用 write（reorg 为这次回退，可能为 nil）；
+// 否则不写入，调用方需要从 Next() 重新处理
+func Commit(ctx context.Context, block uint, hash, prevHash string, write func(reorg *Reorg) error) (*Reorg, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	reorg, err := check(ctx, block, hash, prevHash)
+	if err != nil {
+		return nil, err
+	}
+	if reorg != nil && reorg.Next() < block {
+		return reorg, nil
+	}
+	return reorg, write(reorg)
+}
+
+func check(ctx context.Context, block uint, hash, prevHash string) (*Reorg, error) {
+	local := merklestore.GetLocal()
+
+	r := &Reorg{Tip: tip(local)}
+	switch {
+	case block > 0 && prevHash != "" && differs(localHash(local, block-1), prevHash):
+		// 父区块已经不在主链上，继续向前找分叉点
+		fork, all, err := findFork(ctx, local, block-1)
+		if err != nil {
+			return nil, err
+		}
+		r.Fork, r.All = fork, all
+	case hash != "" && differs(localHash(local, block), hash):
+		if block == 0 {
+			r.All = true
+		} else {
+			r.Fork = block - 1
+		}
+	default:
+		return nil, nil
+	}
+
+	if err := rewind(ctx, r); err != nil {
+		return nil, err
+	}
+	logger.Warnf("chain reorg detected, rewind local data. [block:%d] [fork:%d] [all:%v] [depth:%d]", block, r.Fork, r.All, r.Depth())
+	return r, nil
+}
+
+// differs 本地有记录且与 want 不同
+func differs(stored, want string) bool {
+	return stored != "" && stored != want
+}
+
+// findFork 从 stale（已确认不在主链上）开始向前找分叉点，区块 0 也不在主链上时 all 为 true
+// 没有设置 HashSource 时只能确认 stale 之前的区块，由调用方逐个重新推送
+func findFork(ctx context.Context, local *merklestore.Store, stale uint) (fork uint, all bool, err error) {
+	if stale == 0 {
+		return 0, true, nil
+	}
+	if source == nil {
+		return stale - 1, false, nil
+	}
+
+	for h := stale; h > 0; {
+		h--
+		lh := localHash(local, h)
+		if lh == "" {
+			// 本地没有更早的哈希记录，以这里为分叉点
+			return h, false, nil
+		}
+		ch, err := source(ctx, h)
+		if err != nil {
+			return 0, false, err
+		}
+		if ch == lh {
+			return h, false, nil
+		}
+	}
+	return 0, true, nil
+}
+
+// rewind 删除分叉点之后的本地数据，r.All 时全部删除
+func rewind(ctx context.Context, r *Reorg) error {
+	local := merklestore.GetLocal()
+	heights := local.Heights()
+	for i := len(heights) - 1; i >= 0 && (r.All || heights[i] > r.Fork); i-- {
+		if err := local.Delete(heights[i]); err != nil {
+			return err
+		}
+	}
+
+	db, err := pg.GetDB(constant.DBNameMain)
+	if err == nil {
+		db = db.WithContext(ctx)
+		if err = dao.RollbackFrom(db, r.Next()); err != nil {
+			return err
+		}
+		if err = dao.DeleteBlockChecksFrom(db, r.Next()); err != nil {
+			return err
+		}
+	}
+
+	return state.GetState().Rewind(r.Fork)
+}
+
+// localHash 返回本地保存的区块哈希，文件不存在或没有记录哈希时返回空
+func localHash(s *merklestore.Store, block uint) string {
+	f, err := s.Get(block)
+	if err != nil {
+		if !errors.Is(err, merklestore.ErrNotExist) {
+			logger.Warnf("load merkle file failed. [block:%d] [err:%v]", block, err)
+		}
+		return ""
+	}
+	return f.Hash
+}
+
+func tip(s *merklestore.Store) uint {
+	heights := s.Heights()
+	if len(heights) == 0 {
+		return 0
+	}
+	return heights[len(heights)-1]
+}
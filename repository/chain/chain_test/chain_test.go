@@ -0,0 +1,193 @@
+package chain_test
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"web/config"
+	"web/logger"
+	"web/repository/chain"
+	"web/repository/merklestore"
+	"web/repository/state"
+
+	"go.uber.org/zap"
+)
+
+func init() {
+	logger.ErrorLogger = zap.NewNop().Sugar()
+}
+
+func setup(t *testing.T) {
+	dir := t.TempDir()
+	var cfg config.Configuration
+	cfg.MerkleSetting.FilePath = filepath.Join(dir, "local")
+	cfg.MerkleSetting.RemotePath = filepath.Join(dir, "remote")
+	cfg.RuntimeSetting.RuntimePath = dir
+	merklestore.InitMerkleStore(cfg)
+	state.InitState(cfg)
+	chain.SetHashSource(nil)
+}
+
+func hash(fork string, h uint) string {
+	return fmt.Sprintf("%s-%d", fork, h)
+}
+
+// put 按 fork 链写入 [from, to] 的区块
+func put(t *testing.T, fork string, from, to uint) {
+	for h := from; h <= to; h++ {
+		f := &merklestore.File{Block: h, Hash: hash(fork, h), PrevHash: hash(fork, h-1)}
+		if _, err := merklestore.GetLocal().Put(f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_ = state.GetState().AdvanceLocal(state.FirstLocalHeight, merklestore.GetLocal().Has)
+}
+
+func TestCheckNoReorg(t *testing.T) {
+	setup(t)
+	put(t, "a", 1, 5)
+
+	r, err := chain.Check(context.Background(), 6, hash("a", 6), hash("a", 5))
+	if err != nil || r != nil {
+		t.Fatalf("unexpected reorg %+v (%v)", r, err)
+	}
+	// 重复推送同一个区块
+	if r, _ = chain.Check(context.Background(), 5, hash("a", 5), hash("a", 4)); r != nil {
+		t.Fatalf("unexpected reorg %+v", r)
+	}
+}
+
+func TestCheckReorgWithSource(t *testing.T) {
+	setup(t)
+	put(t, "a", 1, 10)
+	// 新链从 7 开始分叉
+	chain.SetHashSource(func(ctx context.Context, h uint) (string, error) {
+		if h <= 7 {
+			return hash("a", h), nil
+		}
+		return hash("b", h), nil
+	})
+
+	r, err := chain.Check(context.Background(), 11, hash("b", 11), hash("b", 10))
+	if err != nil || r == nil {
+		t.Fatalf("expected reorg (%v)", err)
+	}
+	if r.Fork != 7 || r.Depth() != 3 {
+		t.Fatalf("unexpected reorg %+v", r)
+	}
+	if merklestore.GetLocal().Has(8) || !merklestore.GetLocal().Has(7) {
+		t.Fatal("blocks after fork should be removed")
+	}
+	if st := state.GetState().Get(); st.LocalLastPush != 7 {
+		t.Fatalf("unexpected state %+v", st)
+	}
+}
+
+func TestCheckReorgWithoutSource(t *testing.T) {
+	setup(t)
+	put(t, "a", 1, 5)
+
+	// 同一高度哈希变化，父区块一致
+	r, err := chain.Check(context.Background(), 5, hash("b", 5), hash("a", 4))
+	if err != nil || r == nil || r.Fork != 4 || r.Depth() != 1 {
+		t.Fatalf("unexpected reorg %+v (%v)", r, err)
+	}
+
+	// 父区块不一致时逐个向前回退
+	put(t, "a", 5, 5)
+	r, _ = chain.Check(context.Background(), 6, hash("b", 6), hash("b", 5))
+	if r == nil || r.Fork != 4 {
+		t.Fatalf("unexpected reorg %+v", r)
+	}
+	if st := state.GetState().Get(); st.LocalLastPush != 4 {
+		t.Fatalf("unexpected state %+v", st)
+	}
+}
+
+func TestReorgKeepsRemote(t *testing.T) {
+	setup(t)
+	put(t, "a", 1, 5)
+	if _, err := merklestore.GetRemote().Put(&merklestore.File{Block: 5, Hash: hash("a", 5)}); err != nil {
+		t.Fatal(err)
+	}
+	_ = state.GetState().ObserveRemote(5)
+
+	if r, err := chain.Check(context.Background(), 4, hash("b", 4), hash("a", 3)); err != nil || r == nil || r.Fork != 3 {
+		t.Fatalf("unexpected reorg %+v (%v)", r, err)
+	}
+	if !merklestore.GetRemote().Has(5) || merklestore.GetLocal().Has(5) {
+		t.Fatal("only local blocks should be removed")
+	}
+	if st := state.GetState().Get(); st.RemoteLastPush != 5 || st.LocalLastPush != 3 {
+		t.Fatalf("unexpected state %+v", st)
+	}
+}
+
+func TestCommit(t *testing.T) {
+	setup(t)
+	put(t, "a", 1, 5)
+	ctx := context.Background()
+
+	written := 0
+	write := func(*chain.Reorg) error {
+		written++
+		return nil
+	}
+	if r, err := chain.Commit(ctx, 6, hash("a", 6), hash("a", 5), write); err != nil || r != nil || written != 1 {
+		t.Fatalf("unexpected commit %+v (%v) written %d", r, err, written)
+	}
+	// 同一高度替换，回退后直接写入
+	if r, _ := chain.Commit(ctx, 5, hash("b", 5), hash("a", 4), write); r == nil || written != 2 {
+		t.Fatalf("unexpected commit %+v written %d", r, written)
+	}
+	// 父区块不在本地链上，需要从分叉点重新处理
+	if r, _ := chain.Commit(ctx, 5, hash("c", 5), hash("c", 4), write); r == nil || r.Fork != 3 || written != 2 {
+		t.Fatalf("unexpected commit %+v written %d", r, written)
+	}
+}
+
+func TestReorgAtBlockZero(t *testing.T) {
+	setup(t)
+	put(t, "a", 0, 3)
+
+	// 区块 0 的哈希变化时全部回退，不能下溢
+	r, err := chain.Check(context.Background(), 0, hash("b", 0), "")
+	if err != nil || r == nil || !r.All || r.Next() != 0 || r.Depth() != 4 {
+		t.Fatalf("unexpected reorg %+v (%v)", r, err)
+	}
+	if len(merklestore.GetLocal().Heights()) != 0 {
+		t.Fatalf("all local blocks should be removed %v", merklestore.GetLocal().Heights())
+	}
+	if st := state.GetState().Get(); st.LocalLastPush != 0 || st.CheckerCursor != 0 {
+		t.Fatalf("unexpected state %+v", st)
+	}
+}
+
+func TestFindForkComparesBlockZero(t *testing.T) {
+	setup(t)
+	put(t, "a", 0, 3)
+	ctx := context.Background()
+
+	// 只有区块 0 相同
+	chain.SetHashSource(func(ctx context.Context, h uint) (string, error) {
+		if h == 0 {
+			return hash("a", 0), nil
+		}
+		return hash("b", h), nil
+	})
+	r, err := chain.Check(ctx, 4, hash("b", 4), hash("b", 3))
+	if err != nil || r == nil || r.All || r.Fork != 0 || !merklestore.GetLocal().Has(0) {
+		t.Fatalf("unexpected reorg %+v (%v)", r, err)
+	}
+
+	// 区块 0 也不同
+	put(t, "a", 1, 3)
+	chain.SetHashSource(func(ctx context.Context, h uint) (string, error) {
+		return hash("b", h), nil
+	})
+	r, err = chain.Check(ctx, 4, hash("b", 4), hash("b", 3))
+	if err != nil || r == nil || !r.All || merklestore.GetLocal().Has(0) {
+		t.Fatalf("unexpected reorg %+v (%v)", r, err)
+	}
+}
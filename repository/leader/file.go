@@ -0,0 +1,90 @@
+package leader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// FileElector 文件锁，用于单机多实例或 sqlite 部署；持有锁的进程退出时锁自动释放
+type FileElector struct {
+	path string
+
+	mu sync.Mutex
+	f  *os.File
+}
+
+func NewFileElector(path string) (*FileElector, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	return &FileElector{path: path}, nil
+}
+
+func (e *FileElector) TryAcquire(ctx context.Context) (bool, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.f != nil {
+		// 锁文件被删除或替换后，其他实例可以在新文件上加锁
+		// 只报告失去锁，由 Leader 等任务结束后调用 Release
+		if same, err := e.sameFile(); err != nil || !same {
+			return false, err
+		}
+		return true, nil
+	}
+
+	f, err := os.OpenFile(e.path, os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return false, err
+	}
+	if err = tryLock(f); err != nil {
+		f.Close()
+		if errors.Is(err, errLocked) {
+			return false, nil
+		}
+		return false, err
+	}
+	// 记录持有者，方便排查
+	_ = f.Truncate(0)
+	_, _ = f.WriteAt([]byte(fmt.Sprintf("%d\n", os.Getpid())), 0)
+	e.f = f
+	return true, nil
+}
+
+func (e *FileElector) Release(ctx context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.unlock()
+}
+
+func (e *FileElector) unlock() error {
+	if e.f == nil {
+		return nil
+	}
+	err := errors.Join(unlock(e.f), e.f.Close())
+	e.f = nil
+	return err
+}
+
+func (e *FileElector) sameFile() (bool, error) {
+	held, err := e.f.Stat()
+	if err != nil {
+		return false, err
+	}
+	cur, err := os.Stat(e.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return os.SameFile(held, cur), nil
+}
+
+func (e *FileElector) String() string {
+	return "file:" + e.path
+}
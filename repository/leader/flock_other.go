@@ -0,0 +1,18 @@
+//go:build !unix
+
+package leader
+
+import (
+	"errors"
+	"os"
+)
+
+var errLocked = errors.New("file locked by another process")
+
+func tryLock(f *os.File) error {
+	return errors.New("file lock not supported on this platform")
+}
+
+func unlock(f *os.File) error {
+	return nil
+}
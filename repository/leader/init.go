@@ -0,0 +1,64 @@
+package leader
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+	"web/config"
+	"web/constant"
+	"web/repository/pg"
+)
+
+const (
+	defaultName     = "odin-validator"
+	defaultLockFile = "leader.lock"
+)
+
+var leader *Leader
+
+// InitLeader 按配置创建 leader 选举，需要在 pg.InitPg 之后调用
+func InitLeader(config config.Configuration) error {
+	cfg := config.LeaderSetting
+	name := cfg.Name
+	if name == "" {
+		name = defaultName
+	}
+
+	var (
+		e   Elector
+		err error
+	)
+	switch cfg.Type {
+	case "":
+		return nil
+	case "pg":
+		if config.PostgreCfg.Driver != "" && config.PostgreCfg.Driver != "postgres" {
+			return fmt.Errorf("leader type pg need postgres driver, got %s", config.PostgreCfg.Driver)
+		}
+		db, err := pg.GetDB(constant.DBNameMain)
+		if err != nil {
+			return err
+		}
+		if e, err = NewPgElector(db, name); err != nil {
+			return err
+		}
+	case "file":
+		path := cfg.Path
+		if path == "" {
+			path = filepath.Join(config.RuntimeSetting.RuntimePath, defaultLockFile)
+		}
+		if e, err = NewFileElector(path); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown leader type %s", cfg.Type)
+	}
+
+	leader = New(e, cfg.Interval*time.Second)
+	return nil
+}
+
+// GetLeader 没有配置选举时返回 nil
+func GetLeader() *Leader {
+	return leader
+}
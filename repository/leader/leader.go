@@ -0,0 +1,162 @@
+package leader
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"web/logger"
+)
+
+/*
+	leader 多个实例中只有一个执行 leader-only 的任务
+	1. Elector 负责加锁：postgres advisory lock（pg.go）或者文件锁（file.go）
+	2. Run 定期尝试加锁并确认锁仍然有效，成为 leader 时创建 leader context，失去锁时取消
+	3. 任务通过 Hold 取得 leader context，失去锁或关闭时先取消并等待所有 Hold 释放，再去重新加锁或释放锁，
+	   保证本实例的任务停止后其他实例才可能接手
+	4. Hold 前先确认锁仍然有效（pg 连接断开时服务端已经释放锁），确认失败时立即取消 leader context，
+	   不等下一次定期检查
+*/
+
+// Elector 分布式锁
+type Elector interface {
+	// TryAcquire 尝试加锁，已经持有时确认锁仍然有效
+	// 确认失败时返回 false 但不释放，由调用方停止 leader 任务后 Release
+	TryAcquire(ctx context.Context) (bool, error)
+	// Release 释放锁，没有持有时不做任何事
+	Release(ctx context.Context) error
+	String() string
+}
+
+const defaultInterval = 5 * time.Second
+
+type Leader struct {
+	elector  Elector
+	interval time.Duration
+
+	mu     sync.Mutex
+	ctx    context.Context
+	cancel context.CancelFunc
+	holds  sync.WaitGroup
+
+	// Hold 确认锁失效后通知 Run 立即检查
+	wake chan struct{}
+}
+
+func New(elector Elector, interval time.Duration) *Leader {
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+	return &Leader{elector: elector, interval: interval, wake: make(chan struct{}, 1)}
+}
+
+// IsLeader 当前是否持有锁
+func (l *Leader) IsLeader() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.ctx != nil
+}
+
+// Hold 成为 leader 且锁仍然有效时返回 leader context，失去锁时该 context 被取消；用完后必须调用 release
+func (l *Leader) Hold() (ctx context.Context, release func(), ok bool) {
+	l.mu.Lock()
+	lctx, cancel := l.ctx, l.cancel
+	l.mu.Unlock()
+	if lctx == nil || lctx.Err() != nil {
+		return nil, nil, false
+	}
+
+	// 执行前确认锁仍然有效
+	cctx, cancelCheck := context.WithTimeout(lctx, l.interval)
+	ok, err := l.elector.TryAcquire(cctx)
+	cancelCheck()
+	if err != nil || !ok {
+		logger.Warnf("leader lock lost before run, cancel leader jobs. [lock:%s] [err:%v]", l.elector, err)
+		// 立即取消正在执行的 leader 任务，由 Run 等任务结束后释放锁
+		cancel()
+		select {
+		case l.wake <- struct{}{}:
+		default:
+		}
+		return nil, nil, false
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.ctx != lctx {
+		return nil, nil, false
+	}
+	l.holds.Add(1)
+	var once sync.Once
+	return lctx, func() { once.Do(l.holds.Done) }, true
+}
+
+// Run 定期加锁直到 ctx 取消，退出前停止 leader 任务并释放锁
+func (l *Leader) Run(ctx context.Context) {
+	ticker := time.NewTicker(l.interval)
+	defer ticker.Stop()
+
+	for {
+		l.check(ctx)
+
+		select {
+		case <-ctx.Done():
+			l.resign()
+			// ctx 已经取消，释放锁使用新的 context
+			rctx, cancel := context.WithTimeout(context.Background(), l.interval)
+			if err := l.elector.Release(rctx); err != nil {
+				logger.Errorf("release leader lock failed. [lock:%s] [err:%v]", l.elector, err)
+			}
+			cancel()
+			return
+		case <-ticker.C:
+		case <-l.wake:
+		}
+	}
+}
+
+func (l *Leader) check(ctx context.Context) {
+	ok, err := l.elector.TryAcquire(ctx)
+	if err != nil {
+		logger.Errorf("leader lock check failed. [lock:%s] [err:%v]", l.elector, err)
+		ok = false
+	}
+
+	l.mu.Lock()
+	leading := l.ctx != nil
+	// Hold 确认锁失效时已经取消，即使这次确认成功也要交出 leadership 后重新竞争
+	if leading && l.ctx.Err() != nil {
+		ok = false
+	}
+	l.mu.Unlock()
+
+	switch {
+	case ok && !leading:
+		lctx, cancel := context.WithCancel(context.Background())
+		l.mu.Lock()
+		l.ctx, l.cancel = lctx, cancel
+		l.mu.Unlock()
+		logger.Infof("became leader. [lock:%s]", l.elector)
+	case !ok && leading:
+		l.resign()
+		// 锁可能只是暂时不可用（例如连接断开），显式释放后下一轮重新竞争
+		if err = l.elector.Release(ctx); err != nil {
+			logger.Warnf("release leader lock failed. [lock:%s] [err:%v]", l.elector, err)
+		}
+		logger.Warnf("lost leadership. [lock:%s]", l.elector)
+	}
+}
+
+// resign 取消 leader context 并等待所有 Hold 释放
+func (l *Leader) resign() {
+	l.mu.Lock()
+	cancel := l.cancel
+	l.ctx, l.cancel = nil, nil
+	l.mu.Unlock()
+
+	if cancel == nil {
+		return
+	}
+	cancel()
+	l.holds.Wait()
+}
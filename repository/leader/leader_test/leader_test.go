@@ -0,0 +1,180 @@
+package leader_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+	"web/logger"
+	"web/repository/leader"
+
+	"go.uber.org/zap"
+)
+
+func init() {
+	logger.ErrorLogger = zap.NewNop().Sugar()
+}
+
+func TestFileElector(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "leader.lock")
+	a, _ := leader.NewFileElector(path)
+	b, _ := leader.NewFileElector(path)
+
+	if ok, err := a.TryAcquire(ctx); !ok || err != nil {
+		t.Fatalf("a: %v %v", ok, err)
+	}
+	if ok, err := b.TryAcquire(ctx); ok || err != nil {
+		t.Fatalf("b: %v %v", ok, err)
+	}
+	// 已经持有时确认锁仍然有效
+	if ok, _ := a.TryAcquire(ctx); !ok {
+		t.Fatal("a lost lock")
+	}
+
+	if err := a.Release(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := b.TryAcquire(ctx); !ok {
+		t.Fatal("b not acquired after release")
+	}
+
+	// 锁文件被删除后原来的持有者不再是 leader
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := b.TryAcquire(ctx); ok {
+		t.Fatal("b still leader after lock file removed")
+	}
+	if ok, _ := a.TryAcquire(ctx); !ok {
+		t.Fatal("a not acquired new lock file")
+	}
+	// 失去锁后在 Release 之前一直报告失去，不会重新加锁
+	if ok, _ := b.TryAcquire(ctx); ok {
+		t.Fatal("b leader again before release")
+	}
+	if err := b.Release(ctx); err != nil {
+		t.Fatal(err)
+	}
+	_ = a.Release(ctx)
+	if ok, _ := b.TryAcquire(ctx); !ok {
+		t.Fatal("b not acquired after release")
+	}
+	_ = b.Release(ctx)
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timeout")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestHandover(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "leader.lock")
+	ea, _ := leader.NewFileElector(path)
+	eb, _ := leader.NewFileElector(path)
+	a := leader.New(ea, 10*time.Millisecond)
+	b := leader.New(eb, 10*time.Millisecond)
+
+	ctxA, stopA := context.WithCancel(context.Background())
+	doneA := make(chan struct{})
+	go func() {
+		a.Run(ctxA)
+		close(doneA)
+	}()
+	waitFor(t, a.IsLeader)
+
+	ctxB, stopB := context.WithCancel(context.Background())
+	defer stopB()
+	go b.Run(ctxB)
+	time.Sleep(30 * time.Millisecond)
+	if b.IsLeader() {
+		t.Fatal("two leaders")
+	}
+	if _, _, ok := b.Hold(); ok {
+		t.Fatal("follower hold")
+	}
+
+	lctx, release, ok := a.Hold()
+	if !ok {
+		t.Fatal("leader hold failed")
+	}
+
+	// 关闭时先取消 leader context，等任务结束后才释放锁
+	stopA()
+	select {
+	case <-lctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("leader context not canceled")
+	}
+	time.Sleep(30 * time.Millisecond)
+	if b.IsLeader() {
+		t.Fatal("lock released before holds finished")
+	}
+
+	release()
+	<-doneA
+	waitFor(t, b.IsLeader)
+}
+
+// fakeElector 模拟 pg 连接断开：lost 后确认锁失败
+type fakeElector struct {
+	lost     atomic.Bool
+	released atomic.Int32
+}
+
+func (e *fakeElector) TryAcquire(ctx context.Context) (bool, error) {
+	if e.lost.Load() {
+		return false, errors.New("connection reset")
+	}
+	return true, nil
+}
+
+func (e *fakeElector) Release(ctx context.Context) error {
+	e.released.Add(1)
+	return nil
+}
+
+func (e *fakeElector) String() string {
+	return "fake"
+}
+
+func TestHoldChecksLock(t *testing.T) {
+	e := &fakeElector{}
+	// 定期检查间隔很长，只有 Hold 的确认能发现锁失效
+	l := leader.New(e, time.Hour)
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+	go l.Run(ctx)
+	waitFor(t, l.IsLeader)
+
+	lctx, release, ok := l.Hold()
+	if !ok {
+		t.Fatal("leader hold failed")
+	}
+
+	// 连接断开后下一次执行前发现，立即取消正在执行的任务
+	e.lost.Store(true)
+	if _, _, ok = l.Hold(); ok {
+		t.Fatal("hold should fail after lock lost")
+	}
+	select {
+	case <-lctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("running job not canceled")
+	}
+	if e.released.Load() != 0 {
+		t.Fatal("lock released before running job finished")
+	}
+
+	release()
+	waitFor(t, func() bool { return !l.IsLeader() && e.released.Load() == 1 })
+}
@@ -0,0 +1,82 @@
+package leader
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"hash/fnv"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// PgElector postgres session 级 advisory lock，锁跟随单独占用的一个连接，连接断开时锁自动释放
+type PgElector struct {
+	db   *sql.DB
+	name string
+	key  int64
+
+	mu   sync.Mutex
+	conn *sql.Conn
+}
+
+// NewPgElector name 相同的实例竞争同一把锁
+func NewPgElector(db *gorm.DB, name string) (*PgElector, error) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	h := fnv.New64a()
+	h.Write([]byte(name))
+	return &PgElector{db: sqlDB, name: name, key: int64(h.Sum64())}, nil
+}
+
+func (e *PgElector) TryAcquire(ctx context.Context) (bool, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.conn != nil {
+		// 持有锁的连接还在，锁就还在；失败时由 Leader 等任务结束后调用 Release
+		if err := e.conn.PingContext(ctx); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+
+	conn, err := e.db.Conn(ctx)
+	if err != nil {
+		return false, err
+	}
+	var ok bool
+	if err = conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", e.key).Scan(&ok); err != nil || !ok {
+		conn.Close()
+		return false, err
+	}
+	e.conn = conn
+	return true, nil
+}
+
+func (e *PgElector) Release(ctx context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.conn == nil {
+		return nil
+	}
+	_, err := e.conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", e.key)
+	// 关闭连接时锁一定会释放
+	e.closeConn()
+	return err
+}
+
+func (e *PgElector) closeConn() {
+	// 不放回连接池：连接可能已经损坏，或者 unlock 失败时仍然持有锁
+	_ = e.conn.Raw(func(any) error { return driver.ErrBadConn })
+	e.conn.Close()
+	e.conn = nil
+}
+
+func (e *PgElector) String() string {
+	return fmt.Sprintf("pg:%s", e.name)
+}
@@ -0,0 +1,150 @@
+package merklefetch
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"web/logger"
+	"web/repository/merklestore"
+)
+
+const (
+	defaultRetry   = 3
+	defaultBackoff = 500 * time.Millisecond
+)
+
+// Fetcher 把远端区块文件拉取到本地镜像
+type Fetcher struct {
+	source  Source
+	mirror  *merklestore.Store
+	retry   int
+	backoff time.Duration
+
+	mu    sync.Mutex
+	conds map[uint]Condition
+
+	// OnFetch 每次成功拉取（包括未变化）后回调
+	OnFetch func(block uint)
+}
+
+// NewFetcher retry 为失败后的重试次数，backoff 为首次重试等待时间，之后每次翻倍
+func NewFetcher(source Source, mirror *merklestore.Store, retry int, backoff time.Duration) *Fetcher {
+	if retry < 0 {
+		retry = 0
+	}
+	if backoff <= 0 {
+		backoff = defaultBackoff
+	}
+	return &Fetcher{
+		source:  source,
+		mirror:  mirror,
+		retry:   retry,
+		backoff: backoff,
+		conds:   make(map[uint]Condition),
+	}
+}
+
+// Fetch 拉取区块文件到本地镜像并返回镜像文件路径
+// 远端未变化时不重新下载，直接返回已有的镜像
+func (f *Fetcher) Fetch(ctx context.Context, block uint) (string, error) {
+	cond := f.condition(block)
+
+	res, err := f.fetchWithRetry(ctx, block, cond)
+	if err != nil {
+		return "", err
+	}
+
+	if res.NotModified {
+		if path, err := f.mirror.Path(block); err == nil {
+			f.fetched(block)
+			return path, nil
+		}
+		// 镜像文件丢失，重新完整下载
+		if res, err = f.fetchWithRetry(ctx, block, Condition{}); err != nil {
+			return "", err
+		}
+	}
+
+	if err = verify(block, res); err != nil {
+		return "", err
+	}
+	if _, err = f.mirror.PutRaw(block, res.Body); err != nil {
+		return "", err
+	}
+
+	f.mu.Lock()
+	f.conds[block] = res.Condition
+	f.mu.Unlock()
+	f.fetched(block)
+
+	logger.Infof("fetch remote merkle file success. [block:%d] [etag:%s]", block, res.ETag)
+	return f.mirror.Path(block)
+}
+
+func (f *Fetcher) fetched(block uint) {
+	if f.OnFetch != nil {
+		f.OnFetch(block)
+	}
+}
+
+func (f *Fetcher) condition(block uint) Condition {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// 只有镜像存在时才发条件请求
+	e, ok := f.mirror.Entry(block)
+	if !ok {
+		delete(f.conds, block)
+		return Condition{}
+	}
+	if cond, ok := f.conds[block]; ok {
+		return cond
+	}
+	// 重启后没有缓存的 ETag，使用镜像内容的 sha256（与 DirSource 的 ETag 规则一致）
+	return Condition{ETag: e.Checksum}
+}
+
+func (f *Fetcher) fetchWithRetry(ctx context.Context, block uint, cond Condition) (Result, error) {
+	wait := f.backoff
+	for attempt := 0; ; attempt++ {
+		res, err := f.source.Fetch(ctx, block, cond)
+		if err == nil || !isRetryable(err) || attempt >= f.retry {
+			return res, err
+		}
+
+		logger.Warnf("fetch remote merkle file failed, retry in %v. [block:%d] [attempt:%d] [err:%v]", wait, block, attempt+1, err)
+		select {
+		case <-ctx.Done():
+			return res, ctx.Err()
+		case <-time.After(wait):
+		}
+		wait *= 2
+	}
+}
+
+// verify 校验内容哈希（远端提供时）以及文件本身的叶子和根
+func verify(block uint, res Result) error {
+	if res.Checksum != "" {
+		sum := sha256.Sum256(res.Body)
+		if !strings.EqualFold(hex.EncodeToString(sum[:]), res.Checksum) {
+			return fmt.Errorf("remote merkle file %d content hash mismatch", block)
+		}
+	}
+
+	var file merklestore.File
+	if err := json.Unmarshal(res.Body, &file); err != nil {
+		return fmt.Errorf("remote merkle file %d decode: %w", block, err)
+	}
+	if file.Block != block {
+		return fmt.Errorf("remote merkle file %d has block %d", block, file.Block)
+	}
+	if err := file.Verify(); err != nil {
+		return fmt.Errorf("remote merkle file %d: %w", block, err)
+	}
+	return nil
+}
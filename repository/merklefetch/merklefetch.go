@@ -0,0 +1,42 @@
+package merklefetch
+
+import (
+	"time"
+	"web/config"
+	"web/logger"
+	"web/repository/merklestore"
+	"web/repository/state"
+)
+
+var fetcher *Fetcher
+
+// InitFetcher 根据 merkle.remote_source 初始化远端拉取，未配置时不启用
+// 需要在 merklestore.InitMerkleStore、state.InitState 之后调用
+func InitFetcher(config config.Configuration) {
+	cfg := config.MerkleSetting
+	fetcher = nil
+	if cfg.RemoteSource == "" {
+		return
+	}
+
+	retry := defaultRetry
+	if cfg.RemoteRetry != nil {
+		retry = *cfg.RemoteRetry
+	}
+	fetcher = NewFetcher(
+		NewSource(cfg.RemoteSource, cfg.FileExt),
+		merklestore.GetRemote(),
+		retry,
+		cfg.RemoteBackoff*time.Millisecond,
+	)
+	fetcher.OnFetch = func(block uint) {
+		if err := state.GetState().ObserveRemote(block); err != nil {
+			logger.Errorf("update remote last push failed. [block:%d] [err:%v]", block, err)
+		}
+	}
+}
+
+// GetFetcher 未配置远端时返回 nil
+func GetFetcher() *Fetcher {
+	return fetcher
+}
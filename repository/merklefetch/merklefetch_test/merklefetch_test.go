@@ -0,0 +1,143 @@
+package merklefetch_test
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+	"web/config"
+	"web/logger"
+	"web/repository/merklefetch"
+	"web/repository/merklestore"
+	"web/utils/merkle"
+
+	"go.uber.org/zap"
+)
+
+func init() {
+	logger.ErrorLogger = zap.NewNop().Sugar()
+}
+
+func newFile(block uint) []byte {
+	f := &merklestore.File{
+		Block: block,
+		Ins:   []merklestore.Leaf{{Hash: hex.EncodeToString(merkle.LeafHash([]byte("a"))), Data: "a"}},
+	}
+	f.Root = merkle.New(f.LeafHashes()).RootHex()
+	b, _ := json.Marshal(f)
+	return b
+}
+
+func newMirror(t *testing.T) *merklestore.Store {
+	s, err := merklestore.New(t.TempDir(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestDirSource(t *testing.T) {
+	dir := t.TempDir()
+	_ = os.MkdirAll(filepath.Join(dir, "32"), 0o755)
+	_ = os.WriteFile(filepath.Join(dir, "32", "779832.json"), newFile(779832), 0o644)
+
+	mirror := newMirror(t)
+	f := merklefetch.NewFetcher(merklefetch.NewSource(dir, ""), mirror, 0, 0)
+
+	path, err := f.Fetch(context.Background(), 779832)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != filepath.Join(mirror.Root(), "32", "779832.json") {
+		t.Fatalf("unexpected mirror path %s", path)
+	}
+	if _, err = f.Fetch(context.Background(), 779833); !errors.Is(err, merklefetch.ErrNotExist) {
+		t.Fatalf("expected ErrNotExist, got %v", err)
+	}
+}
+
+func TestHTTPRetryAndConditional(t *testing.T) {
+	var calls, notModified int32
+	body := newFile(100)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		if r.URL.Path != "/0/100.json" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			atomic.AddInt32(&notModified, 1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = w.Write(body)
+	}))
+	defer srv.Close()
+
+	f := merklefetch.NewFetcher(merklefetch.NewSource(srv.URL, ""), newMirror(t), 2, time.Millisecond)
+	if _, err := f.Fetch(context.Background(), 100); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Fetch(context.Background(), 100); err != nil {
+		t.Fatal(err)
+	}
+	if atomic.LoadInt32(&calls) != 3 || atomic.LoadInt32(&notModified) != 1 {
+		t.Fatalf("unexpected calls %d, not modified %d", calls, notModified)
+	}
+}
+
+func TestHashMismatch(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(merklefetch.HeaderContentSha256, "00")
+		_, _ = w.Write(newFile(100))
+	}))
+	defer srv.Close()
+
+	mirror := newMirror(t)
+	f := merklefetch.NewFetcher(merklefetch.NewSource(srv.URL, ""), mirror, 0, 0)
+	if _, err := f.Fetch(context.Background(), 100); err == nil {
+		t.Fatal("expected content hash error")
+	}
+	if mirror.Has(100) {
+		t.Fatal("mismatched file should not be mirrored")
+	}
+}
+
+func TestInitNoRetry(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	var cfg config.Configuration
+	cfg.MerkleSetting.FilePath = t.TempDir()
+	cfg.MerkleSetting.RemotePath = t.TempDir()
+	cfg.MerkleSetting.RemoteSource = srv.URL
+	noRetry := 0
+	cfg.MerkleSetting.RemoteRetry = &noRetry
+	merklestore.InitMerkleStore(cfg)
+	defer merklestore.Close()
+	merklefetch.InitFetcher(cfg)
+
+	// remote_retry 为 0 时只请求一次
+	if _, err := merklefetch.GetFetcher().Fetch(context.Background(), 100); err == nil {
+		t.Fatal("expected error")
+	}
+	if atomic.LoadInt32(&calls) != 1 {
+		t.Fatalf("unexpected calls %d", calls)
+	}
+}
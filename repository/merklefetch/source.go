@@ -0,0 +1,159 @@
+package merklefetch
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+	"web/repository/merklestore"
+)
+
+const (
+	// 远端可选的内容校验头，值为文件内容的 sha256 十六进制
+	HeaderContentSha256 = "X-Content-Sha256"
+
+	defaultHTTPTimeout = 30 * time.Second
+)
+
+var ErrNotExist = errors.New("remote merkle file not exist")
+
+// Condition 条件请求使用的缓存信息
+type Condition struct {
+	ETag         string
+	LastModified string
+}
+
+// Result 一次拉取的结果，NotModified 为 true 时 Body 为空
+type Result struct {
+	Body        []byte
+	Checksum    string
+	NotModified bool
+	Condition
+}
+
+// Source 远端 Merkle 文件来源
+type Source interface {
+	Fetch(ctx context.Context, block uint, cond Condition) (Result, error)
+}
+
+// NewSource 根据配置创建数据来源：http(s) 开头的使用 HTTP，其余视为本地目录
+func NewSource(source, ext string) Source {
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		return &HTTPSource{
+			base:   strings.TrimRight(source, "/"),
+			ext:    ext,
+			client: &http.Client{Timeout: defaultHTTPTimeout},
+		}
+	}
+	return &DirSource{dir: source, ext: ext}
+}
+
+// retryableError 可以重试的错误（网络错误、5xx 等）
+type retryableError struct {
+	err error
+}
+
+func (e *retryableError) Error() string {
+	return e.err.Error()
+}
+
+func (e *retryableError) Unwrap() error {
+	return e.err
+}
+
+func isRetryable(err error) bool {
+	var r *retryableError
+	return errors.As(err, &r)
+}
+
+// HTTPSource 从 <base>/<block % 100>/<block><ext> 拉取文件
+type HTTPSource struct {
+	base   string
+	ext    string
+	client *http.Client
+}
+
+func (s *HTTPSource) Fetch(ctx context.Context, block uint, cond Condition) (Result, error) {
+	var ret Result
+
+	url := s.base + "/" + path.Clean(filepath.ToSlash(merklestore.RelPath(block, s.ext)))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return ret, err
+	}
+	if cond.ETag != "" {
+		req.Header.Set("If-None-Match", cond.ETag)
+	}
+	if cond.LastModified != "" {
+		req.Header.Set("If-Modified-Since", cond.LastModified)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return ret, &retryableError{err}
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified:
+		ret.NotModified = true
+		ret.Condition = cond
+		return ret, nil
+	case resp.StatusCode == http.StatusNotFound:
+		return ret, ErrNotExist
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError:
+		return ret, &retryableError{fmt.Errorf("remote status %d", resp.StatusCode)}
+	case resp.StatusCode != http.StatusOK:
+		return ret, fmt.Errorf("remote status %d", resp.StatusCode)
+	}
+
+	ret.Body, err = io.ReadAll(resp.Body)
+	if err != nil {
+		return ret, &retryableError{err}
+	}
+	ret.Checksum = resp.Header.Get(HeaderContentSha256)
+	ret.ETag = resp.Header.Get("ETag")
+	ret.LastModified = resp.Header.Get("Last-Modified")
+	return ret, nil
+}
+
+// DirSource 从本地目录读取文件，目录结构与 merklestore 一致，主要用于测试
+// 文件内容的 sha256 作为 ETag
+type DirSource struct {
+	dir string
+	ext string
+}
+
+func (s *DirSource) Fetch(ctx context.Context, block uint, cond Condition) (Result, error) {
+	var ret Result
+
+	b, err := os.ReadFile(filepath.Join(s.dir, merklestore.RelPath(block, s.ext)))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return ret, ErrNotExist
+		}
+		return ret, err
+	}
+
+	sum := sha256.Sum256(b)
+	etag := hex.EncodeToString(sum[:])
+	if cond.ETag == etag {
+		ret.NotModified = true
+		ret.Condition = cond
+		return ret, nil
+	}
+
+	ret.Body = b
+	ret.Checksum = etag
+	ret.ETag = etag
+	return ret, nil
+}
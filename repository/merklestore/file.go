@@ -0,0 +1,103 @@
+package merklestore
+
+import (
+	"encoding/hex"
+	"fmt"
+	"web/utils/merkle"
+)
+
+// File 单个区块的 Merkle 数据文件
+type File struct {
+	Block uint   `json:"block"`
+	Root  string `json:"root"`
+	Ins   []Leaf `json:"ins"`
+	Trx   []Leaf `json:"trx"`
+
+	// 区块哈希与父区块哈希，用于检测链重组，旧文件中可能为空
+	Hash     string `json:"hash,omitempty"`
+	PrevHash string `json:"prev_hash,omitempty"`
+}
+
+// Leaf 叶子哈希以及对应的原始记录
+type Leaf struct {
+	Hash string `json:"hash"`
+	Data string `json:"data"`
+}
+
+// NewFile 根据区块的 ins 与 trx 记录计算 Merkle 树
+// 叶子顺序固定为：ins 叶子（按哈希排序）在前，trx 叶子（按哈希排序）在后
+func NewFile(block uint, ins, trx []string) *File {
+	f := &File{
+		Block: block,
+		Ins:   sortedLeaves(ins),
+		Trx:   sortedLeaves(trx),
+	}
+
+	f.Root = merkle.New(f.LeafHashes()).RootHex()
+	return f
+}
+
+func sortedLeaves(records []string) []Leaf {
+	hashes := make([][]byte, 0, len(records))
+	data := make(map[string]string, len(records))
+	for _, r := range records {
+		h := merkle.LeafHash([]byte(r))
+		hashes = append(hashes, h)
+		data[hex.EncodeToString(h)] = r
+	}
+	merkle.SortHashes(hashes)
+
+	leaves := make([]Leaf, 0, len(hashes))
+	for _, h := range hashes {
+		hs := hex.EncodeToString(h)
+		leaves = append(leaves, Leaf{Hash: hs, Data: data[hs]})
+	}
+	return leaves
+}
+
+// Len 返回叶子总数
+func (f *File) Len() int {
+	return len(f.Ins) + len(f.Trx)
+}
+
+// Leaf 按树中的顺序返回第 index 个叶子，ins 在前 trx 在后
+func (f *File) Leaf(index int) Leaf {
+	if index < len(f.Ins) {
+		return f.Ins[index]
+	}
+	return f.Trx[index-len(f.Ins)]
+}
+
+// LeafIndex 返回叶子在树中的位置，不存在时返回 -1
+func (f *File) LeafIndex(hash string) int {
+	for i := 0; i < f.Len(); i++ {
+		if f.Leaf(i).Hash == hash {
+			return i
+		}
+	}
+	return -1
+}
+
+// LeafHashes 按树中的顺序返回所有叶子哈希
+func (f *File) LeafHashes() [][]byte {
+	hashes := make([][]byte, 0, f.Len())
+	for i := 0; i < f.Len(); i++ {
+		h, _ := hex.DecodeString(f.Leaf(i).Hash)
+		hashes = append(hashes, h)
+	}
+	return hashes
+}
+
+// Verify 校验每个叶子哈希与原始记录一致，并且根哈希与叶子一致
+func (f *File) Verify() error {
+	for i := 0; i < f.Len(); i++ {
+		l := f.Leaf(i)
+		if hex.EncodeToString(merkle.LeafHash([]byte(l.Data))) != l.Hash {
+			return fmt.Errorf("leaf %d hash mismatch", i)
+		}
+	}
+	if root := merkle.New(f.LeafHashes()).RootHex(); root != f.Root {
+		return fmt.Errorf("root mismatch: file %s, computed %s", f.Root, root)
+	}
+	return nil
+}
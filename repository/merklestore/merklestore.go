@@ -0,0 +1,53 @@
+package merklestore
+
+import (
+	"errors"
+	"web/config"
+)
+
+var (
+	local  *Store
+	remote *Store
+)
+
+// InitMerkleStore 初始化本地 Merkle 文件存储以及远端数据的本地镜像
+func InitMerkleStore(config config.Configuration) {
+	s, err := New(config.MerkleSetting.FilePath, config.MerkleSetting.FileExt)
+	if err != nil {
+		panic(err)
+	}
+	local = s
+
+	// 没有配置远端时不使用镜像
+	if config.MerkleSetting.RemotePath == "" {
+		if config.MerkleSetting.RemoteSource != "" {
+			panic("merkle.remote_path is required when merkle.remote_source is set")
+		}
+		return
+	}
+	s, err = New(config.MerkleSetting.RemotePath, config.MerkleSetting.FileExt)
+	if err != nil {
+		panic(err)
+	}
+	remote = s
+}
+
+func GetLocal() *Store {
+	return local
+}
+
+// GetRemote 没有配置远端时返回 nil
+func GetRemote() *Store {
+	return remote
+}
+
+// Close 关闭本地存储与远端镜像的索引文件
+func Close() error {
+	var errs []error
+	for _, s := range []*Store{local, remote} {
+		if s != nil {
+			errs = append(errs, s.Close())
+		}
+	}
+	return errors.Join(errs...)
+}
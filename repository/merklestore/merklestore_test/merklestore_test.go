@@ -0,0 +1,109 @@
+package merklestore_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"web/config"
+	"web/repository/merklestore"
+)
+
+func TestPutGet(t *testing.T) {
+	root := t.TempDir()
+	s, err := merklestore.New(root, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	path, err := s.Put(&merklestore.File{Block: 779832, Root: "r"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != filepath.Join(root, "32", "779832.json") {
+		t.Fatalf("unexpected path %s", path)
+	}
+
+	f, err := s.Get(779832)
+	if err != nil || f.Root != "r" {
+		t.Fatalf("get failed: %v %+v", err, f)
+	}
+	if _, err = s.Get(779833); !errors.Is(err, merklestore.ErrNotExist) {
+		t.Fatalf("expected ErrNotExist, got %v", err)
+	}
+}
+
+func TestReloadIndex(t *testing.T) {
+	root := t.TempDir()
+	s, _ := merklestore.New(root, "")
+	_, _ = s.Put(&merklestore.File{Block: 1})
+	_, _ = s.Put(&merklestore.File{Block: 101})
+	s.Close()
+
+	// 残留的临时文件、未写入索引的文件
+	_ = os.WriteFile(filepath.Join(root, "1", "201.json.123.tmp"), []byte("half"), 0o644)
+	_ = os.MkdirAll(filepath.Join(root, "2"), 0o755)
+	_ = os.WriteFile(filepath.Join(root, "2", "2.json"), []byte(`{"block":2}`), 0o644)
+
+	s, err := merklestore.New(root, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	heights := s.Heights()
+	if len(heights) != 3 || heights[0] != 1 || heights[1] != 2 || heights[2] != 101 {
+		t.Fatalf("unexpected heights %v", heights)
+	}
+	if _, err = s.Get(2); err != nil {
+		t.Fatalf("file renamed before index append should be recovered: %v", err)
+	}
+	if _, err = os.Stat(filepath.Join(root, "1", "201.json.123.tmp")); !os.IsNotExist(err) {
+		t.Fatal("temp file should be removed")
+	}
+}
+
+func TestChecksumMismatch(t *testing.T) {
+	root := t.TempDir()
+	s, _ := merklestore.New(root, "")
+	defer s.Close()
+
+	path, _ := s.Put(&merklestore.File{Block: 5, Root: "r"})
+	_ = os.WriteFile(path, []byte(`{"block":5,"root":"x"}`), 0o644)
+
+	if _, err := s.Get(5); !errors.Is(err, merklestore.ErrChecksum) {
+		t.Fatalf("expected ErrChecksum, got %v", err)
+	}
+}
+
+func TestDelete(t *testing.T) {
+	root := t.TempDir()
+	s, _ := merklestore.New(root, "")
+	_, _ = s.Put(&merklestore.File{Block: 1})
+	_, _ = s.Put(&merklestore.File{Block: 2})
+	if err := s.Delete(2); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Delete(3); err != nil {
+		t.Fatalf("delete missing block: %v", err)
+	}
+	s.Close()
+
+	s, _ = merklestore.New(root, "")
+	defer s.Close()
+	if heights := s.Heights(); len(heights) != 1 || heights[0] != 1 {
+		t.Fatalf("unexpected heights %v", heights)
+	}
+}
+
+func TestInitWithoutRemote(t *testing.T) {
+	var cfg config.Configuration
+	cfg.MerkleSetting.FilePath = t.TempDir()
+	merklestore.InitMerkleStore(cfg)
+	defer merklestore.Close()
+
+	if merklestore.GetLocal() == nil || merklestore.GetRemote() != nil {
+		t.Fatalf("unexpected stores local %v remote %v", merklestore.GetLocal(), merklestore.GetRemote())
+	}
+}
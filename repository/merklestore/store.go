@@ -0,0 +1,341 @@
+package merklestore
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"web/utils/atomicfile"
+)
+
+/*
+	merklestore 按区块高度保存 Merkle 文件
+	1. 文件路径：<root>/<block % ShardCount>/<block><ext>
+	2. 写入先落临时文件并 fsync，再 rename 覆盖，保证不会出现写了一半的文件
+	3. 每个文件的 sha256 记录在 <root>/index.log 中，读取时校验；删除时追加 del 记录
+	4. 启动时重放 index.log 并与目录内容对账，清理残留的临时文件
+*/
+
+const (
+	ShardCount     = 100
+	DefaultFileExt = ".json"
+
+	indexFileName = "index.log"
+	indexOpPut    = "put"
+	indexOpDel    = "del"
+)
+
+var (
+	ErrNotExist = errors.New("merkle file not exist")
+	ErrChecksum = errors.New("merkle file checksum mismatch")
+)
+
+// Entry 索引中记录的单个文件信息
+type Entry struct {
+	Checksum string
+	Size     int64
+}
+
+type Store struct {
+	root string
+	ext  string
+
+	mu    sync.RWMutex
+	index map[uint]Entry
+	log   *os.File
+}
+
+// New 打开（不存在时创建）root 目录下的 Merkle 文件存储
+func New(root, ext string) (*Store, error) {
+	if ext == "" {
+		ext = DefaultFileExt
+	}
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, err
+	}
+
+	s := &Store{
+		root:  root,
+		ext:   ext,
+		index: make(map[uint]Entry),
+	}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+
+	log, err := os.OpenFile(s.indexPath(), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	s.log = log
+	return s, nil
+}
+
+// Close 关闭索引文件
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.log.Close()
+}
+
+// Root 返回存储根目录
+func (s *Store) Root() string {
+	return s.root
+}
+
+// Put 保存区块的 Merkle 文件，返回文件路径
+func (s *Store) Put(f *File) (string, error) {
+	b, err := json.Marshal(f)
+	if err != nil {
+		return "", err
+	}
+	return s.PutRaw(f.Block, b)
+}
+
+// PutRaw 原子写入区块文件内容并记录校验和，返回文件路径
+func (s *Store) PutRaw(block uint, b []byte) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path := s.filePath(block)
+	if err := atomicfile.WriteFile(path, b, 0o644); err != nil {
+		return "", err
+	}
+
+	e := Entry{Checksum: checksum(b), Size: int64(len(b))}
+	if err := s.appendIndex(block, e); err != nil {
+		return "", err
+	}
+	s.index[block] = e
+	return path, nil
+}
+
+// Delete 删除区块文件，文件不存在时不做任何事
+func (s *Store) Delete(block uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.index[block]; !ok {
+		return nil
+	}
+	// 先删除文件，删除后未写入索引时启动对账会补齐
+	if err := os.Remove(s.filePath(block)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if _, err := fmt.Fprintf(s.log, "%s %d\n", indexOpDel, block); err != nil {
+		return err
+	}
+	if err := s.log.Sync(); err != nil {
+		return err
+	}
+	delete(s.index, block)
+	return nil
+}
+
+// Get 读取并解析区块的 Merkle 文件
+func (s *Store) Get(block uint) (*File, error) {
+	b, err := s.GetRaw(block)
+	if err != nil {
+		return nil, err
+	}
+
+	var f File
+	if err = json.Unmarshal(b, &f); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// GetRaw 读取区块文件内容并校验校验和
+func (s *Store) GetRaw(block uint) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	e, ok := s.index[block]
+	if !ok {
+		return nil, ErrNotExist
+	}
+
+	b, err := os.ReadFile(s.filePath(block))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, ErrNotExist
+		}
+		return nil, err
+	}
+	if checksum(b) != e.Checksum {
+		return nil, ErrChecksum
+	}
+	return b, nil
+}
+
+// Path 返回区块文件的绝对路径
+func (s *Store) Path(block uint) (string, error) {
+	if !s.Has(block) {
+		return "", ErrNotExist
+	}
+	return filepath.Abs(s.filePath(block))
+}
+
+// Has 判断区块文件是否存在
+func (s *Store) Has(block uint) bool {
+	_, ok := s.Entry(block)
+	return ok
+}
+
+// Entry 返回区块文件的索引信息
+func (s *Store) Entry(block uint) (Entry, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	e, ok := s.index[block]
+	return e, ok
+}
+
+// Heights 按升序返回所有已保存的区块高度
+func (s *Store) Heights() []uint {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	heights := make([]uint, 0, len(s.index))
+	for h := range s.index {
+		heights = append(heights, h)
+	}
+	sort.Slice(heights, func(i, j int) bool { return heights[i] < heights[j] })
+	return heights
+}
+
+func (s *Store) filePath(block uint) string {
+	return filepath.Join(s.root, RelPath(block, s.ext))
+}
+
+// RelPath 返回区块文件相对于存储根目录的路径
+func RelPath(block uint, ext string) string {
+	if ext == "" {
+		ext = DefaultFileExt
+	}
+	return filepath.Join(strconv.Itoa(int(block%ShardCount)), fmt.Sprintf("%d%s", block, ext))
+}
+
+func (s *Store) indexPath() string {
+	return filepath.Join(s.root, indexFileName)
+}
+
+func (s *Store) appendIndex(block uint, e Entry) error {
+	if _, err := fmt.Fprintf(s.log, "%s %d %s %d\n", indexOpPut, block, e.Checksum, e.Size); err != nil {
+		return err
+	}
+	return s.log.Sync()
+}
+
+// load 重放索引并与磁盘上的文件对账，最后把压缩后的索引原子写回
+func (s *Store) load() error {
+	if err := s.readIndex(); err != nil {
+		return err
+	}
+
+	onDisk := make(map[uint]string)
+	err := filepath.WalkDir(s.root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		name := d.Name()
+		if strings.HasSuffix(name, atomicfile.TmpSuffix) {
+			// 上次退出时没有完成的写入
+			return os.Remove(path)
+		}
+		if !strings.HasSuffix(name, s.ext) {
+			return nil
+		}
+		h, err := strconv.ParseUint(strings.TrimSuffix(name, s.ext), 10, 64)
+		if err != nil || filepath.Dir(path) != filepath.Dir(s.filePath(uint(h))) {
+			return nil
+		}
+		onDisk[uint(h)] = path
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for h := range s.index {
+		if _, ok := onDisk[h]; !ok {
+			delete(s.index, h)
+		}
+	}
+	for h, path := range onDisk {
+		if _, ok := s.index[h]; ok {
+			continue
+		}
+		// rename 已完成但索引未写入，按文件内容补齐
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		s.index[h] = Entry{Checksum: checksum(b), Size: int64(len(b))}
+	}
+
+	return s.writeIndex()
+}
+
+func (s *Store) readIndex() error {
+	f, err := os.Open(s.indexPath())
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && fields[0] == indexOpDel {
+			if h, err := strconv.ParseUint(fields[1], 10, 64); err == nil {
+				delete(s.index, uint(h))
+			}
+			continue
+		}
+		// 最后一行可能只写了一半，直接忽略
+		if len(fields) != 4 || fields[0] != indexOpPut {
+			continue
+		}
+		h, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		size, err := strconv.ParseInt(fields[3], 10, 64)
+		if err != nil {
+			continue
+		}
+		s.index[uint(h)] = Entry{Checksum: fields[2], Size: size}
+	}
+	return scanner.Err()
+}
+
+func (s *Store) writeIndex() error {
+	var sb strings.Builder
+	for _, h := range s.Heights() {
+		e := s.index[h]
+		fmt.Fprintf(&sb, "%s %d %s %d\n", indexOpPut, h, e.Checksum, e.Size)
+	}
+	return atomicfile.WriteFile(s.indexPath(), []byte(sb.String()), 0o644)
+}
+
+func checksum(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
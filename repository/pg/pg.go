@@ -14,6 +14,8 @@ import (
 	"gorm.io/gorm/schema"
 )
 
+const defaultDriver = "postgres"
+
 var dbMap map[string]*gorm.DB
 
 func init() {
@@ -26,7 +28,7 @@ func InitPg(config config.Configuration) bool {
 			continue
 		}
 
-		db, err := initPg(cfg, config.Log)
+		db, err := initPg(config.PostgreCfg.Driver, cfg, config.Log)
 		if err == nil && db != nil {
 			dbMap[name] = db
 		} else {
@@ -36,11 +38,14 @@ func InitPg(config config.Configuration) bool {
 	return true
 }
 
-func initPg(path string, logCfg dlog.Conf) (*gorm.DB, error) {
+func initPg(driver, path string, logCfg dlog.Conf) (*gorm.DB, error) {
 	var (
 		db  *gorm.DB
 		err error
 	)
+	if driver == "" {
+		driver = defaultDriver
+	}
 	// dlog.Entry.Errorf("failed init db.[ path = %s]", path)
 	myLog, _ := dlog.InitLog(dlog.Conf{
 		LogLevel: logCfg.LogLevel,
@@ -61,7 +66,7 @@ func initPg(path string, logCfg dlog.Conf) (*gorm.DB, error) {
 		// 配置驱动，可选驱动到`https://git.safeis.cn/safeis/safeis-lib/-/tree/main/database/opens.go`
 		// database.WithDriver("sqlite3"),
 		// database.WithDSN("test.db"),
-		database.WithDriver("postgres"),
+		database.WithDriver(driver),
 		database.WithDSN(path),
 		database.WithMaxIdleConns(2),
 		database.WithMaxOpenConns(10),
@@ -93,3 +98,18 @@ func GetDB(name string) (*gorm.DB, error) {
 
 	return nil, errors.New(errS)
 }
+
+// Close 关闭所有数据库连接池
+func Close() error {
+	var errs []error
+	for name, db := range dbMap {
+		sqlDB, err := db.DB()
+		if err == nil {
+			err = sqlDB.Close()
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("close db %s: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,50 @@
+package state
+
+import (
+	"path/filepath"
+	"web/config"
+	"web/repository/merklestore"
+)
+
+const defaultStateFile = "state.json"
+
+var st *Store
+
+// InitState 加载运行状态并与 Merkle 文件存储对账
+// 需要在 merklestore.InitMerkleStore 之后调用
+func InitState(config config.Configuration) {
+	name := config.RuntimeSetting.RuntimeFile
+	if name == "" {
+		name = defaultStateFile
+	}
+
+	s, err := Open(filepath.Join(config.RuntimeSetting.RuntimePath, name))
+	if err != nil {
+		panic(err)
+	}
+
+	if err = s.AdvanceLocal(FirstLocalHeight, merklestore.GetLocal().Has); err != nil {
+		panic(err)
+	}
+	if r := merklestore.GetRemote(); r != nil {
+		if heights := r.Heights(); len(heights) > 0 {
+			if err = s.ObserveRemote(heights[len(heights)-1]); err != nil {
+				panic(err)
+			}
+		}
+	}
+	st = s
+}
+
+func GetState() *Store {
+	return st
+}
+
+// FirstLocalHeight 本地最低的区块高度，没有文件时返回 0
+func FirstLocalHeight() uint {
+	heights := merklestore.GetLocal().Heights()
+	if len(heights) == 0 {
+		return 0
+	}
+	return heights[0]
+}
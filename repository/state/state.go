@@ -0,0 +1,135 @@
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"sync"
+	"web/utils/atomicfile"
+)
+
+/*
+	state 保存在 runtime 目录下的运行状态文件，每次修改都原子写回
+*/
+
+// State 运行状态
+type State struct {
+	// 本地已连续构建的最高区块
+	LocalLastPush uint `json:"local_last_push"`
+	// 远端已看到的最高区块
+	RemoteLastPush uint `json:"remote_last_push"`
+	// checker 已经检查到的区块
+	CheckerCursor uint `json:"checker_cursor"`
+	// recheck 上一批检查到的缺失区块，下一批从其后继续，到末尾后从头开始
+	RecheckCursor uint `json:"recheck_cursor"`
+}
+
+type Store struct {
+	path string
+
+	mu    sync.RWMutex
+	state State
+}
+
+// Open 打开状态文件，文件不存在时使用零值
+func Open(path string) (*Store, error) {
+	s := &Store{path: path}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return s, nil
+		}
+		return nil, err
+	}
+	if err = json.Unmarshal(b, &s.state); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Get 返回当前状态的副本
+func (s *Store) Get() State {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.state
+}
+
+// Update 修改状态并持久化，写入失败时内存中的状态不变
+func (s *Store) Update(fn func(st *State)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	next := s.state
+	fn(&next)
+	if next == s.state {
+		return nil
+	}
+
+	b, err := json.Marshal(next)
+	if err != nil {
+		return err
+	}
+	if err = atomicfile.WriteFile(s.path, b, 0o644); err != nil {
+		return err
+	}
+	s.state = next
+	return nil
+}
+
+// ObserveRemote 记录远端出现的区块，只会增大
+func (s *Store) ObserveRemote(block uint) error {
+	return s.Update(func(st *State) {
+		if block > st.RemoteLastPush {
+			st.RemoteLastPush = block
+		}
+	})
+}
+
+// SetCheckerCursor 记录 checker 已经检查到的区块
+func (s *Store) SetCheckerCursor(block uint) error {
+	return s.Update(func(st *State) {
+		st.CheckerCursor = block
+	})
+}
+
+// SetRecheckCursor 记录 recheck 已经检查到的缺失区块，0 表示下一批从头开始
+func (s *Store) SetRecheckCursor(block uint) error {
+	return s.Update(func(st *State) {
+		st.RecheckCursor = block
+	})
+}
+
+// AdvanceLocal 从 LocalLastPush 开始向后推进到最后一个连续存在的区块
+// LocalLastPush 为 0 或者对应区块已不存在时，从 first() 开始重新计算
+func (s *Store) AdvanceLocal(first func() uint, has func(block uint) bool) error {
+	return s.Update(func(st *State) {
+		if st.LocalLastPush == 0 || !has(st.LocalLastPush) {
+			st.LocalLastPush = 0
+			start := first()
+			if start == 0 || !has(start) {
+				return
+			}
+			st.LocalLastPush = start
+		}
+		for has(st.LocalLastPush + 1) {
+			st.LocalLastPush++
+		}
+	})
+}
+
+// Rewind 链重组后回退到 block，大于 block 的本地进度全部作废，远端进度不变
+func (s *Store) Rewind(block uint) error {
+	return s.Update(func(st *State) {
+		if st.LocalLastPush > block {
+			st.LocalLastPush = block
+		}
+		if st.CheckerCursor > block {
+			st.CheckerCursor = block
+		}
+		if st.RecheckCursor > block {
+			st.RecheckCursor = block
+		}
+	})
+}
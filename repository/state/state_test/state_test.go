@@ -0,0 +1,37 @@
+package state_test
+
+import (
+	"path/filepath"
+	"testing"
+	"web/repository/state"
+)
+
+func TestAdvanceLocal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	s, err := state.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	blocks := map[uint]bool{10: true, 11: true, 13: true}
+	has := func(b uint) bool { return blocks[b] }
+	first := func() uint { return 10 }
+
+	if err = s.AdvanceLocal(first, has); err != nil || s.Get().LocalLastPush != 11 {
+		t.Fatalf("expected 11, got %d (%v)", s.Get().LocalLastPush, err)
+	}
+
+	blocks[12] = true
+	_ = s.AdvanceLocal(first, has)
+	if s.Get().LocalLastPush != 13 {
+		t.Fatalf("expected 13, got %d", s.Get().LocalLastPush)
+	}
+
+	_ = s.ObserveRemote(20)
+	_ = s.ObserveRemote(15)
+
+	s, _ = state.Open(path)
+	if st := s.Get(); st.LocalLastPush != 13 || st.RemoteLastPush != 20 {
+		t.Fatalf("state not persisted: %+v", st)
+	}
+}
@@ -0,0 +1,53 @@
+package atomicfile
+
+import (
+	"os"
+	"path/filepath"
+)
+
+/*
+	atomicfile 提供原子写文件能力
+	写临时文件、fsync 后 rename 覆盖，最后 fsync 所在目录，进程中途退出不会留下写了一半的文件
+*/
+
+// TmpSuffix 临时文件后缀，残留的临时文件可以按此后缀清理
+const TmpSuffix = ".tmp"
+
+// WriteFile 原子写入文件
+func WriteFile(path string, b []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".*"+TmpSuffix)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err = tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	if err = os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
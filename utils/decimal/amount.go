@@ -0,0 +1,229 @@
+package decimal
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strings"
+	"web/constant"
+)
+
+/*
+	Amount 定点数，数值以最小单位（value * 10^dec）保存在 big.Int 中
+	1. 解析严格：只允许数字和一个小数点，不允许符号、指数、空白，小数点两侧都必须有数字
+	2. 数值不能超过 2^64-1（constant.UINT64_MAX_S），不能为负
+	3. 运算返回错误，不会静默得到 0
+	4. 不同精度的数值运算时结果取较大的精度
+*/
+
+// MaxDecimals BRC-20 允许的最大精度
+const MaxDecimals = 18
+
+var (
+	ErrFormat   = errors.New("decimal: invalid amount format")
+	ErrDecimals = errors.New("decimal: too many decimal places")
+	ErrOverflow = errors.New("decimal: amount overflow")
+	ErrNegative = errors.New("decimal: negative amount")
+	ErrScale    = errors.New("decimal: invalid decimals")
+)
+
+var (
+	ten       = big.NewInt(10)
+	maxAmount = func() *big.Int {
+		v, _ := new(big.Int).SetString(constant.UINT64_MAX_S, 10)
+		return v
+	}()
+)
+
+type Amount struct {
+	v   *big.Int
+	dec uint8
+}
+
+// Zero 返回指定精度的 0
+func Zero(dec uint8) Amount {
+	return Amount{v: new(big.Int), dec: dec}
+}
+
+// NewAmount 严格解析数量，小数位数不能超过 dec
+func NewAmount(s string, dec uint8) (Amount, error) {
+	if dec > MaxDecimals {
+		return Amount{}, ErrScale
+	}
+
+	intPart, fracPart, hasDot := strings.Cut(s, ".")
+	if !isDigits(intPart) || (hasDot && !isDigits(fracPart)) {
+		return Amount{}, ErrFormat
+	}
+	if len(fracPart) > int(dec) {
+		return Amount{}, ErrDecimals
+	}
+
+	v, ok := new(big.Int).SetString(intPart+fracPart+strings.Repeat("0", int(dec)-len(fracPart)), 10)
+	if !ok {
+		return Amount{}, ErrFormat
+	}
+	a := Amount{v: v, dec: dec}
+	if err := a.check(); err != nil {
+		return Amount{}, err
+	}
+	return a, nil
+}
+
+// MustAmount 解析失败时 panic，只用于常量
+func MustAmount(s string, dec uint8) Amount {
+	a, err := NewAmount(s, dec)
+	if err != nil {
+		panic(err)
+	}
+	return a
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Dec 返回精度
+func (a Amount) Dec() uint8 {
+	return a.dec
+}
+
+func (a Amount) value() *big.Int {
+	if a.v == nil {
+		return new(big.Int)
+	}
+	return a.v
+}
+
+// Rescale 转换精度，缩小精度会丢失数值时返回 ErrDecimals
+func (a Amount) Rescale(dec uint8) (Amount, error) {
+	if dec > MaxDecimals {
+		return Amount{}, ErrScale
+	}
+	v := new(big.Int).Set(a.value())
+	if dec >= a.dec {
+		v.Mul(v, pow10(dec-a.dec))
+		return Amount{v: v, dec: dec}, nil
+	}
+
+	q, r := new(big.Int).QuoRem(v, pow10(a.dec-dec), new(big.Int))
+	if r.Sign() != 0 {
+		return Amount{}, ErrDecimals
+	}
+	return Amount{v: q, dec: dec}, nil
+}
+
+// Add 加法，结果超过 2^64-1 时返回 ErrOverflow
+func (a Amount) Add(b Amount) (Amount, error) {
+	x, y, dec := align(a, b)
+	r := Amount{v: x.Add(x, y), dec: dec}
+	if err := r.check(); err != nil {
+		return Amount{}, err
+	}
+	return r, nil
+}
+
+// Sub 减法，结果为负时返回 ErrNegative
+func (a Amount) Sub(b Amount) (Amount, error) {
+	x, y, dec := align(a, b)
+	r := Amount{v: x.Sub(x, y), dec: dec}
+	if err := r.check(); err != nil {
+		return Amount{}, err
+	}
+	return r, nil
+}
+
+// Cmp 比较大小，a < b 返回 -1，相等返回 0，a > b 返回 1
+func (a Amount) Cmp(b Amount) int {
+	x, y, _ := align(a, b)
+	return x.Cmp(y)
+}
+
+func (a Amount) IsZero() bool {
+	return a.value().Sign() == 0
+}
+
+func (a Amount) IsPositive() bool {
+	return a.value().Sign() > 0
+}
+
+// Min 返回较小的数
+func Min(a, b Amount) Amount {
+	if a.Cmp(b) <= 0 {
+		return a
+	}
+	return b
+}
+
+// String 返回最简十进制表示，没有多余的 0
+func (a Amount) String() string {
+	s := a.value().String()
+	if a.dec == 0 {
+		return s
+	}
+	if len(s) <= int(a.dec) {
+		s = strings.Repeat("0", int(a.dec)-len(s)+1) + s
+	}
+
+	intPart, fracPart := s[:len(s)-int(a.dec)], strings.TrimRight(s[len(s)-int(a.dec):], "0")
+	if fracPart == "" {
+		return intPart
+	}
+	return intPart + "." + fracPart
+}
+
+// MarshalJSON 以字符串输出，避免精度丢失
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.String())
+}
+
+// UnmarshalJSON 只接受字符串，按 MaxDecimals 精度解析
+func (a *Amount) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return ErrFormat
+	}
+	r, err := NewAmount(s, MaxDecimals)
+	if err != nil {
+		return err
+	}
+	*a = r
+	return nil
+}
+
+func (a Amount) check() error {
+	v := a.value()
+	if v.Sign() < 0 {
+		return ErrNegative
+	}
+	if v.Cmp(new(big.Int).Mul(maxAmount, pow10(a.dec))) > 0 {
+		return ErrOverflow
+	}
+	return nil
+}
+
+// align 把两个数转换到相同精度，返回可以直接修改的副本
+func align(a, b Amount) (*big.Int, *big.Int, uint8) {
+	x, y := new(big.Int).Set(a.value()), new(big.Int).Set(b.value())
+	switch {
+	case a.dec > b.dec:
+		y.Mul(y, pow10(a.dec-b.dec))
+		return x, y, a.dec
+	case a.dec < b.dec:
+		x.Mul(x, pow10(b.dec-a.dec))
+		return x, y, b.dec
+	}
+	return x, y, a.dec
+}
+
+func pow10(n uint8) *big.Int {
+	return new(big.Int).Exp(ten, big.NewInt(int64(n)), nil)
+}
@@ -51,6 +51,8 @@ func MulStringDecimals(amount string, precision int) string {
 }
 
 // 计算代币除以精度后的总额
+//
+// Deprecated: 解析失败时按 0 继续计算，新代码使用 Amount。
 func DivStringDecimals(tokenTotalSupply string, precision int) string {
 	tokenTotalSupplyDecimal, err := decimal.NewFromString(tokenTotalSupply)
 	if err != nil {
@@ -61,6 +63,7 @@ func DivStringDecimals(tokenTotalSupply string, precision int) string {
 	return tokenTotalSupplyDecimalNumber.Round(18).String()
 }
 
+// Deprecated: 解析失败时按 0 继续计算，新代码使用 Amount。
 func GreaterOrEqual(a, b string) bool {
 	aDecimal, err := decimal.NewFromString(a)
 	if err != nil {
@@ -74,6 +77,8 @@ func GreaterOrEqual(a, b string) bool {
 }
 
 // 返回是否超过指定值
+//
+// Deprecated: 解析失败时按 0 继续计算，新代码使用 Amount。
 func Greater(a, b string) bool {
 	aDecimal, err := decimal.NewFromString(a)
 	if err != nil {
@@ -86,6 +91,7 @@ func Greater(a, b string) bool {
 	return aDecimal.GreaterThan(bDecimal)
 }
 
+// Deprecated: 解析失败时按 0 继续计算，新代码使用 Amount。
 func LessThan(a, b string) bool {
 	aDecimal, err := decimal.NewFromString(a)
 	if err != nil {
@@ -98,6 +104,7 @@ func LessThan(a, b string) bool {
 	return aDecimal.LessThan(bDecimal)
 }
 
+// Deprecated: 解析失败时按 0 继续计算，新代码使用 Amount。
 func LessThanOrEqual(a, b string) bool {
 	aDecimal, err := decimal.NewFromString(a)
 	if err != nil {
@@ -110,6 +117,7 @@ func LessThanOrEqual(a, b string) bool {
 	return aDecimal.LessThanOrEqual(bDecimal)
 }
 
+// Deprecated: 解析失败时按 0 继续计算，新代码使用 Amount。
 func Mul(a, b string) string {
 	aDecimal, err := decimal.NewFromString(a)
 	if err != nil {
@@ -122,6 +130,7 @@ func Mul(a, b string) string {
 	return aDecimal.Mul(bDecimal).Round(18).String()
 }
 
+// Deprecated: 解析失败时按 0 继续计算，新代码使用 Amount。
 func Sub(a, b string) string {
 	aDecimal, err := decimal.NewFromString(a)
 	if err != nil {
@@ -134,6 +143,7 @@ func Sub(a, b string) string {
 	return aDecimal.Sub(bDecimal).Round(18).String()
 }
 
+// Deprecated: 解析失败时按 0 继续计算，新代码使用 Amount。
 func Add(a, b string) string {
 	aDecimal, err := decimal.NewFromString(a)
 	if err != nil {
@@ -0,0 +1,76 @@
+package decimal_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"web/utils/decimal"
+)
+
+func TestNewAmount(t *testing.T) {
+	cases := []struct {
+		s   string
+		dec uint8
+		err error
+		out string
+	}{
+		{"1", 0, nil, "1"},
+		{"1.50", 2, nil, "1.5"},
+		{"0.000000000000000001", 18, nil, "0.000000000000000001"},
+		{"18446744073709551615", 18, nil, "18446744073709551615"},
+		{"18446744073709551616", 0, decimal.ErrOverflow, ""},
+		{"1.001", 2, decimal.ErrDecimals, ""},
+		{"+1", 18, decimal.ErrFormat, ""},
+		{"-1", 18, decimal.ErrFormat, ""},
+		{"1e5", 18, decimal.ErrFormat, ""},
+		{".5", 18, decimal.ErrFormat, ""},
+		{"5.", 18, decimal.ErrFormat, ""},
+		{" 5", 18, decimal.ErrFormat, ""},
+		{"", 18, decimal.ErrFormat, ""},
+		{"1", 19, decimal.ErrScale, ""},
+	}
+	for _, c := range cases {
+		a, err := decimal.NewAmount(c.s, c.dec)
+		if !errors.Is(err, c.err) {
+			t.Errorf("%q: want err %v got %v", c.s, c.err, err)
+			continue
+		}
+		if err == nil && a.String() != c.out {
+			t.Errorf("%q: want %s got %s", c.s, c.out, a)
+		}
+	}
+}
+
+func TestArithmetic(t *testing.T) {
+	max := decimal.MustAmount("18446744073709551615", 2)
+	if _, err := max.Add(decimal.MustAmount("0.01", 2)); !errors.Is(err, decimal.ErrOverflow) {
+		t.Fatalf("want overflow, got %v", err)
+	}
+	if _, err := decimal.MustAmount("1", 0).Sub(decimal.MustAmount("1.5", 1)); !errors.Is(err, decimal.ErrNegative) {
+		t.Fatalf("want negative, got %v", err)
+	}
+
+	r, err := decimal.MustAmount("1", 0).Add(decimal.MustAmount("0.25", 2))
+	if err != nil || r.String() != "1.25" || r.Dec() != 2 {
+		t.Fatalf("unexpected add %s %d %v", r, r.Dec(), err)
+	}
+	if _, err = r.Rescale(1); !errors.Is(err, decimal.ErrDecimals) {
+		t.Fatalf("want rescale error, got %v", err)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	var v struct {
+		Amt decimal.Amount `json:"amt"`
+	}
+	if err := json.Unmarshal([]byte(`{"amt":"12.5"}`), &v); err != nil || v.Amt.String() != "12.5" {
+		t.Fatalf("unmarshal %s %v", v.Amt, err)
+	}
+	if err := json.Unmarshal([]byte(`{"amt":12.5}`), &v); err == nil {
+		t.Fatal("number should be rejected")
+	}
+	b, _ := json.Marshal(v)
+	if string(b) != `{"amt":"12.5"}` {
+		t.Fatalf("marshal %s", b)
+	}
+}
@@ -0,0 +1,129 @@
+package merkle
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+)
+
+/*
+	merkle 提供确定性的 Merkle 树计算
+	1. 叶子哈希：sha256(0x00 || data)
+	2. 节点哈希：sha256(0x01 || left || right)
+	3. 某一层节点数为奇数时，最后一个节点直接提升到上一层（不复制，避免 CVE-2012-2459 类问题）
+	4. 空树的根为 sha256("")
+*/
+
+const (
+	leafPrefix = byte(0x00)
+	nodePrefix = byte(0x01)
+)
+
+type Tree struct {
+	// levels[0] 为叶子层，levels[len-1] 只有根节点
+	levels [][][]byte
+}
+
+// LeafHash 计算一条原始记录的叶子哈希
+func LeafHash(data []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{leafPrefix})
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+// NodeHash 计算两个子节点的父节点哈希
+func NodeHash(left, right []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{nodePrefix})
+	h.Write(left)
+	h.Write(right)
+	return h.Sum(nil)
+}
+
+// SortHashes 按字节序排序叶子哈希，保证同一集合得到同一棵树
+func SortHashes(hashes [][]byte) {
+	sort.SliceStable(hashes, func(i, j int) bool {
+		return bytes.Compare(hashes[i], hashes[j]) < 0
+	})
+}
+
+// New 使用已经计算好的叶子哈希构建 Merkle 树，叶子顺序由调用方决定
+func New(leaves [][]byte) *Tree {
+	t := &Tree{}
+	level := make([][]byte, len(leaves))
+	copy(level, leaves)
+	t.levels = append(t.levels, level)
+
+	for len(level) > 1 {
+		next := make([][]byte, 0, (len(level)+1)/2)
+		for i := 0; i < len(level); i += 2 {
+			if i+1 == len(level) {
+				next = append(next, level[i])
+				continue
+			}
+			next = append(next, NodeHash(level[i], level[i+1]))
+		}
+		t.levels = append(t.levels, next)
+		level = next
+	}
+	return t
+}
+
+// Root 返回根哈希
+func (t *Tree) Root() []byte {
+	top := t.levels[len(t.levels)-1]
+	if len(top) == 0 {
+		sum := sha256.Sum256(nil)
+		return sum[:]
+	}
+	return top[0]
+}
+
+// RootHex 返回十六进制编码的根哈希
+func (t *Tree) RootHex() string {
+	return hex.EncodeToString(t.Root())
+}
+
+// Leaves 返回叶子数量
+func (t *Tree) Leaves() int {
+	return len(t.levels[0])
+}
+
+// ProofNode 证明路径上的一个兄弟节点，Left 表示兄弟节点位于左侧
+type ProofNode struct {
+	Hash []byte
+	Left bool
+}
+
+// Proof 返回第 index 个叶子到根的兄弟节点路径
+func (t *Tree) Proof(index int) ([]ProofNode, bool) {
+	if index < 0 || index >= t.Leaves() {
+		return nil, false
+	}
+
+	path := make([]ProofNode, 0, len(t.levels)-1)
+	for _, level := range t.levels[:len(t.levels)-1] {
+		sibling := index ^ 1
+		// 奇数层的最后一个节点被直接提升，没有兄弟节点
+		if sibling < len(level) {
+			path = append(path, ProofNode{Hash: level[sibling], Left: sibling < index})
+		}
+		index /= 2
+	}
+	return path, true
+}
+
+// Verify 无状态校验叶子是否包含在给定根中
+func Verify(leaf []byte, path []ProofNode, root []byte) bool {
+	h := leaf
+	for _, n := range path {
+		if n.Left {
+			h = NodeHash(n.Hash, h)
+		} else {
+			h = NodeHash(h, n.Hash)
+		}
+	}
+	return bytes.Equal(h, root)
+}
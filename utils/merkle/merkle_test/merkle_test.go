@@ -0,0 +1,60 @@
+package merkle_test
+
+import (
+	"bytes"
+	"testing"
+	"web/utils/merkle"
+)
+
+func TestRootDeterministic(t *testing.T) {
+	a := [][]byte{merkle.LeafHash([]byte("a")), merkle.LeafHash([]byte("b")), merkle.LeafHash([]byte("c"))}
+	b := [][]byte{a[2], a[0], a[1]}
+	merkle.SortHashes(a)
+	merkle.SortHashes(b)
+
+	if !bytes.Equal(merkle.New(a).Root(), merkle.New(b).Root()) {
+		t.Fatal("sorted leaves should give the same root")
+	}
+}
+
+func TestOddLeafPromoted(t *testing.T) {
+	l := [][]byte{merkle.LeafHash([]byte("a")), merkle.LeafHash([]byte("b")), merkle.LeafHash([]byte("c"))}
+	want := merkle.NodeHash(merkle.NodeHash(l[0], l[1]), l[2])
+
+	if got := merkle.New(l).Root(); !bytes.Equal(got, want) {
+		t.Fatalf("unexpected root %x", got)
+	}
+}
+
+func TestSingleAndEmpty(t *testing.T) {
+	l := merkle.LeafHash([]byte("a"))
+	if !bytes.Equal(merkle.New([][]byte{l}).Root(), l) {
+		t.Fatal("single leaf tree root should be the leaf")
+	}
+	if merkle.New(nil).RootHex() != "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855" {
+		t.Fatal("empty tree root should be sha256 of empty input")
+	}
+}
+
+func TestProofVerify(t *testing.T) {
+	for n := 1; n <= 9; n++ {
+		leaves := make([][]byte, 0, n)
+		for i := 0; i < n; i++ {
+			leaves = append(leaves, merkle.LeafHash([]byte{byte(i)}))
+		}
+		tree := merkle.New(leaves)
+
+		for i := 0; i < n; i++ {
+			path, ok := tree.Proof(i)
+			if !ok {
+				t.Fatalf("no proof for leaf %d of %d", i, n)
+			}
+			if !merkle.Verify(leaves[i], path, tree.Root()) {
+				t.Fatalf("proof for leaf %d of %d does not verify", i, n)
+			}
+			if merkle.Verify(merkle.LeafHash([]byte("x")), path, tree.Root()) {
+				t.Fatalf("proof for leaf %d of %d verifies a wrong leaf", i, n)
+			}
+		}
+	}
+}
@@ -1,7 +1,58 @@
 package utils
 
-import "web/utils/snowflake"
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"time"
+	"web/config"
+	"web/constant"
+	"web/dao"
+	"web/logger"
+	"web/repository/pg"
+	"web/utils/snowflake"
+)
 
-func InitUtils() {
-	snowflake.SetUpSnowFlakeWorker(0, 0)
+var lease *snowflake.Lease
+
+// InitUtils 设置雪花算法的机器 ID：配置了 worker_id 时直接使用，否则从数据库租用
+// 需要在 pg.InitPg 与 dao.AutoMigrate 之后调用
+func InitUtils(ctx context.Context, config config.Configuration) error {
+	cfg := config.Snowflake
+	if cfg.WorkerID != nil {
+		return snowflake.SetUpSnowFlakeWorker(*cfg.WorkerID, cfg.DataCenterID)
+	}
+
+	db, err := pg.GetDB(constant.DBNameMain)
+	if err != nil {
+		// 无法保证多个实例的 worker ID 不重复，只适合单实例部署
+		logger.Warnf("no db to lease snowflake worker id, use 0. [err:%v]", err)
+		return snowflake.SetUpSnowFlakeWorker(0, cfg.DataCenterID)
+	}
+
+	l := snowflake.NewLease(dao.SnowflakeLeaseStore{DB: db}, cfg.DataCenterID, leaseOwner(), cfg.LeaseTTL*time.Second)
+	if err = l.Start(ctx); err != nil {
+		return err
+	}
+	lease = l
+	logger.Infof("snowflake lease acquired. [datacenter:%d] [worker:%d]", cfg.DataCenterID, l.WorkerID())
+	return nil
+}
+
+// CloseUtils 释放租用的 worker ID
+func CloseUtils(ctx context.Context) error {
+	if lease == nil {
+		return nil
+	}
+	return lease.Stop(ctx)
+}
+
+// leaseOwner 主机名、进程号加随机后缀，同一台机器上的多个进程以及重启后的进程也要区分开
+func leaseOwner() string {
+	host, _ := os.Hostname()
+	suffix := make([]byte, 4)
+	_, _ = rand.Read(suffix)
+	return fmt.Sprintf("%s-%d-%s", host, os.Getpid(), hex.EncodeToString(suffix))
 }
@@ -0,0 +1,157 @@
+package snowflake
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+	"web/logger"
+)
+
+/*
+	多实例部署时 worker ID 通过租约分配：
+	1. Start 从 LeaseStore 占用一个空闲或已过期的 worker ID
+	2. 后台每 ttl/3 续约一次；租约被其他实例占用时停止生成并重新租用
+	3. 续约一直失败、租约即将过期时停止生成，避免与接手该 worker ID 的实例生成重复的 id
+	4. Stop 停止生成并释放租约
+*/
+
+// LeaseStore 保存 worker ID 的租约，同一 dataCenterID 下的一个 workerID 同时只属于一个 owner
+type LeaseStore interface {
+	// Acquire 占用 [0, maxWorkerID] 中一个空闲或已过期的 worker ID，没有可用的时 ok 为 false
+	Acquire(ctx context.Context, dataCenterID, maxWorkerID int64, owner string, expiresAt time.Time) (workerID int64, ok bool, err error)
+	// Renew 延长租约，租约已经属于其他 owner 时返回 false
+	Renew(ctx context.Context, dataCenterID, workerID int64, owner string, expiresAt time.Time) (bool, error)
+	// Release 删除属于 owner 的租约
+	Release(ctx context.Context, dataCenterID, workerID int64, owner string) error
+}
+
+const defaultLeaseTTL = 30 * time.Second
+
+// ErrNoWorkerID 所有 worker ID 都被占用
+var ErrNoWorkerID = errors.New("no free snowflake worker id")
+
+type Lease struct {
+	store        LeaseStore
+	dataCenterID int64
+	owner        string
+	ttl          time.Duration
+
+	mu        sync.Mutex
+	workerID  int64
+	expiresAt time.Time
+	cancel    context.CancelFunc
+	done      chan struct{}
+}
+
+// NewLease owner 用于区分实例，ttl 为 0 时使用默认 30 秒
+func NewLease(store LeaseStore, dataCenterID int64, owner string, ttl time.Duration) *Lease {
+	if ttl <= 0 {
+		ttl = defaultLeaseTTL
+	}
+	return &Lease{store: store, dataCenterID: dataCenterID, owner: owner, ttl: ttl}
+}
+
+// WorkerID 当前租用的 worker ID
+func (l *Lease) WorkerID() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.workerID
+}
+
+// Start 租用 worker ID 并设置 GlobalSnowFlakeWorker，之后在后台续约直到 Stop
+func (l *Lease) Start(ctx context.Context) error {
+	if err := validate(0, l.dataCenterID); err != nil {
+		return err
+	}
+	if err := l.acquire(ctx); err != nil {
+		return err
+	}
+	if err := SetUpSnowFlakeWorker(l.WorkerID(), l.dataCenterID); err != nil {
+		return err
+	}
+
+	var rctx context.Context
+	rctx, l.cancel = context.WithCancel(context.Background())
+	l.done = make(chan struct{})
+	go l.run(rctx)
+	return nil
+}
+
+// Stop 停止续约与生成 id，然后释放租约
+func (l *Lease) Stop(ctx context.Context) error {
+	if l.cancel == nil {
+		return nil
+	}
+	l.cancel()
+	select {
+	case <-l.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	GlobalSnowFlakeWorker.disable()
+	return l.store.Release(ctx, l.dataCenterID, l.WorkerID(), l.owner)
+}
+
+func (l *Lease) acquire(ctx context.Context) error {
+	expiresAt := time.Now().Add(l.ttl)
+	id, ok, err := l.store.Acquire(ctx, l.dataCenterID, maxWorkerID, l.owner, expiresAt)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("%w. [datacenter:%d]", ErrNoWorkerID, l.dataCenterID)
+	}
+	l.mu.Lock()
+	l.workerID, l.expiresAt = id, expiresAt
+	l.mu.Unlock()
+	return nil
+}
+
+func (l *Lease) run(ctx context.Context) {
+	defer close(l.done)
+
+	ticker := time.NewTicker(l.ttl / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			l.renew(ctx)
+		}
+	}
+}
+
+func (l *Lease) renew(ctx context.Context) {
+	l.mu.Lock()
+	workerID, current := l.workerID, l.expiresAt
+	l.mu.Unlock()
+
+	expiresAt := time.Now().Add(l.ttl)
+	ok, err := l.store.Renew(ctx, l.dataCenterID, workerID, l.owner, expiresAt)
+	switch {
+	case err != nil:
+		logger.Errorf("renew snowflake lease failed. [worker:%d] [err:%v]", workerID, err)
+		// 下一次续约前租约就会过期，其他实例可能接手该 worker ID
+		if time.Until(current) < l.ttl/3 {
+			GlobalSnowFlakeWorker.disable()
+		}
+		return
+	case !ok:
+		logger.Warnf("snowflake lease taken by others, acquire a new one. [worker:%d]", workerID)
+		GlobalSnowFlakeWorker.disable()
+		if err = l.acquire(ctx); err != nil {
+			logger.Errorf("acquire snowflake lease failed. [err:%v]", err)
+			return
+		}
+		workerID = l.WorkerID()
+		logger.Infof("snowflake lease acquired. [datacenter:%d] [worker:%d]", l.dataCenterID, workerID)
+	default:
+		l.mu.Lock()
+		l.expiresAt = expiresAt
+		l.mu.Unlock()
+	}
+	GlobalSnowFlakeWorker.reset(workerID, l.dataCenterID)
+}
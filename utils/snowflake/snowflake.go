@@ -2,6 +2,7 @@ package snowflake
 
 import (
 	"errors"
+	"fmt"
 	"sync"
 	"time"
 )
@@ -36,17 +37,35 @@ const (
 
 var GlobalSnowFlakeWorker *SnowFlakeWorker
 
+// ErrWorkerDisabled worker ID 的租约已经失效，不能保证生成的 id 唯一
+var ErrWorkerDisabled = errors.New("snowflake worker disabled")
+
 type SnowFlakeWorker struct {
 	mu           sync.Mutex
 	LastStamp    int64 // 记录上一次ID的时间戳
 	WorkerID     int64 // 该节点的ID
 	DataCenterID int64 // 该节点的 数据中心ID
 	Sequence     int64 // 当前毫秒已经生成的ID序列号(从0 开始累加) 1毫秒内最多生成4096个ID
+	disabled     bool
 }
 
 // SetUpSnowFlakeWorker 雪花算法支持最大 32 个服务器集群，单集群最大 32 台机器的部署方式，因此 worker，center 取值均为 0 - 31（5位整数）
-func SetUpSnowFlakeWorker(worker,center int64) {
+func SetUpSnowFlakeWorker(worker, center int64) error {
+	if err := validate(worker, center); err != nil {
+		return err
+	}
 	GlobalSnowFlakeWorker = newSnowFlakeWorker(worker, center)
+	return nil
+}
+
+func validate(workerID, dataCenterID int64) error {
+	if workerID < 0 || workerID > maxWorkerID {
+		return fmt.Errorf("snowflake worker id %d out of range [0, %d]", workerID, maxWorkerID)
+	}
+	if dataCenterID < 0 || dataCenterID > maxDataCenterID {
+		return fmt.Errorf("snowflake datacenter id %d out of range [0, %d]", dataCenterID, maxDataCenterID)
+	}
+	return nil
 }
 
 func newSnowFlakeWorker(workerID, dataCenterID int64) *SnowFlakeWorker {
@@ -58,6 +77,21 @@ func newSnowFlakeWorker(workerID, dataCenterID int64) *SnowFlakeWorker {
 	}
 }
 
+// reset 切换 worker ID 并恢复生成，LastStamp 保留，同一进程内的 id 不会回退
+func (w *SnowFlakeWorker) reset(workerID, dataCenterID int64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.WorkerID, w.DataCenterID = workerID, dataCenterID
+	w.disabled = false
+}
+
+// disable 之后 NextID 返回 ErrWorkerDisabled，直到 reset
+func (w *SnowFlakeWorker) disable() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.disabled = true
+}
+
 func (w *SnowFlakeWorker) getMilliSeconds() int64 {
 	return time.Now().UnixNano() / 1e6
 }
@@ -70,6 +104,9 @@ func (w *SnowFlakeWorker) NextID() (uint64, error) {
 }
 
 func (w *SnowFlakeWorker) nextID() (uint64, error) {
+	if w.disabled {
+		return 0, ErrWorkerDisabled
+	}
 	timeStamp := w.getMilliSeconds()
 	if timeStamp < w.LastStamp {
 		return 0, errors.New("time is moving backwards,waiting until")
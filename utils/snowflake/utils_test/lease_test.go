@@ -0,0 +1,152 @@
+package utils_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+	"web/logger"
+	"web/utils/snowflake"
+
+	"go.uber.org/zap"
+)
+
+func init() {
+	logger.ErrorLogger = zap.NewNop().Sugar()
+}
+
+type leaseKey struct{ dc, worker int64 }
+
+type memStore struct {
+	mu     sync.Mutex
+	leases map[leaseKey]string
+}
+
+func (s *memStore) Acquire(_ context.Context, dc, max int64, owner string, _ time.Time) (int64, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id := int64(0); id <= max; id++ {
+		if _, ok := s.leases[leaseKey{dc, id}]; !ok {
+			s.leases[leaseKey{dc, id}] = owner
+			return id, true, nil
+		}
+	}
+	return 0, false, nil
+}
+
+func (s *memStore) Renew(_ context.Context, dc, worker int64, owner string, _ time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.leases[leaseKey{dc, worker}] == owner, nil
+}
+
+func (s *memStore) Release(_ context.Context, dc, worker int64, owner string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.leases[leaseKey{dc, worker}] == owner {
+		delete(s.leases, leaseKey{dc, worker})
+	}
+	return nil
+}
+
+func (s *memStore) set(dc, worker int64, owner string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.leases[leaseKey{dc, worker}] = owner
+}
+
+func TestSetUpValidate(t *testing.T) {
+	for _, c := range [][2]int64{{-1, 0}, {32, 0}, {0, -1}, {0, 32}} {
+		if err := snowflake.SetUpSnowFlakeWorker(c[0], c[1]); err == nil {
+			t.Fatalf("expect error for %v", c)
+		}
+	}
+	if err := snowflake.SetUpSnowFlakeWorker(31, 31); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLease(t *testing.T) {
+	store := &memStore{leases: map[leaseKey]string{{3, 0}: "other"}}
+	l := snowflake.NewLease(store, 3, "me", 30*time.Millisecond)
+	if err := l.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if l.WorkerID() != 1 {
+		t.Fatalf("unexpected worker %d", l.WorkerID())
+	}
+
+	// 租约被抢走后换一个 worker ID 继续生成
+	store.set(3, 1, "other")
+	deadline := time.Now().Add(time.Second)
+	for l.WorkerID() != 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if _, err := snowflake.GlobalSnowFlakeWorker.NextID(); err != nil || l.WorkerID() != 2 {
+		t.Fatalf("expect worker 2, got %d (%v)", l.WorkerID(), err)
+	}
+
+	if err := l.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := snowflake.GlobalSnowFlakeWorker.NextID(); !errors.Is(err, snowflake.ErrWorkerDisabled) {
+		t.Fatalf("expect disabled after stop, got %v", err)
+	}
+	if _, ok := store.leases[leaseKey{3, 2}]; ok {
+		t.Fatal("lease not released")
+	}
+}
+
+// flakyStore 续约交替失败，覆盖续约各分支与读取并发的情况
+type flakyStore struct {
+	*memStore
+	calls atomic.Int32
+}
+
+func (s *flakyStore) Renew(ctx context.Context, dc, worker int64, owner string, expiresAt time.Time) (bool, error) {
+	if s.calls.Add(1)%2 == 0 {
+		return false, errors.New("db down")
+	}
+	return s.memStore.Renew(ctx, dc, worker, owner, expiresAt)
+}
+
+func TestLeaseRenewConcurrent(t *testing.T) {
+	store := &flakyStore{memStore: &memStore{leases: map[leaseKey]string{}}}
+	l := snowflake.NewLease(store, 4, "me", 15*time.Millisecond)
+	if err := l.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					_ = l.WorkerID()
+					_, _ = snowflake.GlobalSnowFlakeWorker.NextID()
+				}
+			}
+		}()
+	}
+	deadline := time.Now().Add(time.Second)
+	for store.calls.Load() < 6 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	close(stop)
+	wg.Wait()
+
+	if store.calls.Load() < 6 {
+		t.Fatalf("renew not running, calls %d", store.calls.Load())
+	}
+	if err := l.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,101 @@
+package api
+
+import (
+	"net/http"
+	"reflect"
+	"web/web/models"
+)
+
+/*
+	api 集中声明所有接口的方法、路径以及请求（T）与响应（R）类型
+	1. router 按这里的声明注册处理函数，T/R 与处理函数不一致时无法编译
+	2. client 按这里的声明生成调用，保证与服务端一致
+*/
+
+// Route 单个接口的声明
+type Route[T any, R any] struct {
+	Info
+}
+
+// Info 接口的元信息，不带类型参数，用于遍历所有接口
+type Info struct {
+	Name    string
+	Method  string
+	Path    string
+	Summary string
+	// 已废弃的接口，保留用于兼容
+	Deprecated bool
+
+	Request  reflect.Type
+	Response reflect.Type
+}
+
+var routes []Info
+
+func newRoute[T any, R any](name, method, path, summary string) Route[T, R] {
+	info := Info{
+		Name:     name,
+		Method:   method,
+		Path:     path,
+		Summary:  summary,
+		Request:  reflect.TypeOf((*T)(nil)).Elem(),
+		Response: reflect.TypeOf((*R)(nil)).Elem(),
+	}
+	routes = append(routes, info)
+	return Route[T, R]{Info: info}
+}
+
+func deprecated[T any, R any](r Route[T, R]) Route[T, R] {
+	r.Deprecated = true
+	routes[len(routes)-1].Deprecated = true
+	return r
+}
+
+// Routes 按声明顺序返回所有接口
+func Routes() []Info {
+	ret := make([]Info, len(routes))
+	copy(ret, routes)
+	return ret
+}
+
+// server test
+var Ping = newRoute[models.PingReq, models.PingResp](
+	"Ping", http.MethodGet, "/api/ping", "Check that the server is up")
+
+// merkle data
+var (
+	BuildMerkle = newRoute[models.BuildMerkleRequest, models.BuildMerkleResponse](
+		"BuildMerkle", http.MethodPut, "/api/merkle/build", "Build and store the Merkle file of a block")
+	GetMerkleProof = newRoute[models.GetMerkleProofRequest, models.GetMerkleProofResponse](
+		"GetMerkleProof", http.MethodGet, "/api/merkle/proof", "Inclusion proof of a single record")
+	VerifyMerkleProof = newRoute[models.VerifyMerkleProofRequest, models.VerifyMerkleProofResponse](
+		"VerifyMerkleProof", http.MethodPost, "/api/merkle/verify", "Verify an inclusion proof without local data")
+	GetMerkleFile = newRoute[models.GetMerkleFileRequest, models.GetMerkleFileResp](
+		"GetMerkleFile", http.MethodGet, "/api/merkle/file/get", "Path of the local or mirrored Merkle file of a block")
+	GetLastPush = newRoute[models.GetLastPushRequest, models.GetLastPushResponse](
+		"GetLastPush", http.MethodGet, "/api/merkle/last", "Last contiguous local block and last remote block")
+)
+
+// validator web
+var (
+	GetDiff = newRoute[models.WebDiffReq, models.WebDiffResp](
+		"GetDiff", http.MethodGet, "/api/web/diff", "Records that differ between the local and remote Merkle file")
+	GetChecked = newRoute[models.WebCheckedReq, models.WebCheckedResp](
+		"GetChecked", http.MethodGet, "/api/web/checked", "Latest checker results")
+	GetList = newRoute[models.WebListReq, models.WebListResp](
+		"GetList", http.MethodGet, "/api/web/list", "Checker results with filters and cursor paging")
+)
+
+// admin
+var (
+	ListJobs = newRoute[models.ListJobsReq, models.ListJobsResp](
+		"ListJobs", http.MethodGet, "/api/admin/jobs", "Jobs with their last runs and next run")
+	TriggerJob = newRoute[models.TriggerJobReq, models.TriggerJobResp](
+		"TriggerJob", http.MethodPost, "/api/admin/jobs/trigger", "Run a job now")
+	PauseJob = newRoute[models.PauseJobReq, models.PauseJobResp](
+		"PauseJob", http.MethodPost, "/api/admin/jobs/pause", "Pause or resume the schedule of a job")
+)
+
+// 兼容旧的对比接口
+var GetInsDiff = deprecated(newRoute[models.WebDiffReq, models.WebDiffResp](
+	"GetInsDiff", http.MethodGet, "/api/test/ins/diff", "Same as /api/web/diff"))
@@ -0,0 +1,77 @@
+package api_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"web/web/api"
+)
+
+func TestOpenAPI(t *testing.T) {
+	doc := api.OpenAPI()
+	for _, r := range api.Routes() {
+		if _, ok := doc.Paths[r.Path]; !ok {
+			t.Fatalf("missing path %s", r.Path)
+		}
+	}
+
+	list := doc.Paths["/api/web/list"]["get"]
+	params := map[string]api.Parameter{}
+	for _, p := range list.Parameters {
+		params[p.Name] = p
+	}
+	if limit := params["limit"]; limit.In != "query" || *limit.Schema.Maximum != 1000 {
+		t.Fatalf("unexpected limit parameter %+v", limit.Schema)
+	}
+	if sort := params["sort"]; len(sort.Schema.Enum) != 2 {
+		t.Fatalf("unexpected sort parameter %+v", sort.Schema)
+	}
+	if status := params["status"]; status.Schema.Type != "array" {
+		t.Fatalf("unexpected status parameter %+v", status.Schema)
+	}
+	// 0 是合法的区块高度，block 不标记为必填
+	if block := doc.Paths["/api/web/diff"]["get"].Parameters[0]; block.Name != "block" || block.Required || *block.Schema.Minimum != 0 {
+		t.Fatalf("unexpected block parameter %+v", block)
+	}
+
+	build := doc.Paths["/api/merkle/build"]["put"]
+	body := doc.Components.Schemas["BuildMerkleRequest"]
+	if build.RequestBody == nil || body == nil || body.Properties["prev_hash"] == nil || body.Properties["block"] == nil {
+		t.Fatalf("unexpected build request %+v", body)
+	}
+	data := build.Responses["200"].Content["application/json"].Schema.Properties["data"]
+	if data.Ref != "#/components/schemas/BuildMerkleResponse" {
+		t.Fatalf("response should be wrapped in the envelope %+v", data)
+	}
+
+	if _, err := json.Marshal(doc); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBindingConstraints(t *testing.T) {
+	type req struct {
+		Name   string   `json:"name" binding:"min=1,max=64"`
+		Tags   []string `json:"tags" binding:"max=10"`
+		Limit  int      `json:"limit" binding:"min=1,max=1000"`
+		Status uint     `json:"status" binding:"oneof=1 2 5"`
+		Sort   string   `json:"sort" binding:"oneof=asc desc"`
+	}
+	s := api.SchemaOf(reflect.TypeOf(req{}), "json")
+
+	if name := s.Properties["name"]; name.MinLength == nil || *name.MinLength != 1 || *name.MaxLength != 64 || name.Minimum != nil {
+		t.Fatalf("string min/max should be length %+v", name)
+	}
+	if tags := s.Properties["tags"]; tags.MaxItems == nil || *tags.MaxItems != 10 || tags.Maximum != nil {
+		t.Fatalf("slice max should be items %+v", tags)
+	}
+	if limit := s.Properties["limit"]; *limit.Minimum != 1 || *limit.Maximum != 1000 || limit.MaxLength != nil {
+		t.Fatalf("integer min/max should be value %+v", limit)
+	}
+	if status := s.Properties["status"]; !reflect.DeepEqual(status.Enum, []any{int64(1), int64(2), int64(5)}) {
+		t.Fatalf("integer enum should be numbers %+v", status.Enum)
+	}
+	if sort := s.Properties["sort"]; !reflect.DeepEqual(sort.Enum, []any{"asc", "desc"}) {
+		t.Fatalf("unexpected string enum %+v", sort.Enum)
+	}
+}
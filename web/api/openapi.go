@@ -0,0 +1,324 @@
+package api
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"web/common"
+)
+
+/*
+	openapi 根据接口声明生成 OpenAPI 3 文档
+	1. GET 请求参数按 form 标签生成 query 参数，其他请求按 json 标签生成 JSON body
+	2. binding 标签中的 required、min、max、oneof 转换为对应的约束
+	3. 响应统一包装在 {data, code, msg, request_id} 中
+*/
+
+const (
+	openAPIVersion = "3.0.3"
+	Title          = "Odin-validator"
+	Version        = "1.0.0"
+
+	schemaRef     = "#/components/schemas/"
+	errorResponse = "Error"
+)
+
+// Schema OpenAPI schema 中用到的部分
+type Schema struct {
+	Ref         string             `json:"$ref,omitempty"`
+	Type        string             `json:"type,omitempty"`
+	Format      string             `json:"format,omitempty"`
+	Description string             `json:"description,omitempty"`
+	Properties  map[string]*Schema `json:"properties,omitempty"`
+	Required    []string           `json:"required,omitempty"`
+	Items       *Schema            `json:"items,omitempty"`
+	Enum        []any              `json:"enum,omitempty"`
+	Minimum     *float64           `json:"minimum,omitempty"`
+	Maximum     *float64           `json:"maximum,omitempty"`
+	MinLength   *int               `json:"minLength,omitempty"`
+	MaxLength   *int               `json:"maxLength,omitempty"`
+	MinItems    *int               `json:"minItems,omitempty"`
+	MaxItems    *int               `json:"maxItems,omitempty"`
+	Nullable    bool               `json:"nullable,omitempty"`
+}
+
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required,omitempty"`
+	Schema   *Schema `json:"schema"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type Response struct {
+	Ref         string               `json:"$ref,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type Operation struct {
+	OperationID string              `json:"operationId"`
+	Summary     string              `json:"summary,omitempty"`
+	Deprecated  bool                `json:"deprecated,omitempty"`
+	Parameters  []Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]Response `json:"responses"`
+}
+
+type Document struct {
+	OpenAPI    string                           `json:"openapi"`
+	Info       map[string]string                `json:"info"`
+	Paths      map[string]map[string]*Operation `json:"paths"`
+	Components struct {
+		Schemas   map[string]*Schema  `json:"schemas"`
+		Responses map[string]Response `json:"responses"`
+	} `json:"components"`
+}
+
+// OpenAPI 根据 Routes 生成文档
+func OpenAPI() *Document {
+	d := &Document{
+		OpenAPI: openAPIVersion,
+		Info:    map[string]string{"title": Title, "version": Version},
+		Paths:   make(map[string]map[string]*Operation),
+	}
+	g := &generator{schemas: make(map[string]*Schema)}
+
+	for _, r := range Routes() {
+		op := &Operation{
+			OperationID: r.Name,
+			Summary:     r.Summary,
+			Deprecated:  r.Deprecated,
+			Responses: map[string]Response{
+				strconv.Itoa(http.StatusOK): {
+					Description: "Success",
+					Content:     jsonContent(envelope(g.schema(r.Response, "json"))),
+				},
+				"default": {Ref: "#/components/responses/" + errorResponse},
+			},
+		}
+		if r.Method == http.MethodGet {
+			op.Parameters = g.parameters(r.Request)
+		} else {
+			op.RequestBody = &RequestBody{Required: true, Content: jsonContent(g.schema(r.Request, "json"))}
+		}
+
+		if d.Paths[r.Path] == nil {
+			d.Paths[r.Path] = make(map[string]*Operation)
+		}
+		d.Paths[r.Path][strings.ToLower(r.Method)] = op
+	}
+
+	d.Components.Schemas = g.schemas
+	d.Components.Schemas["FieldError"] = g.schema(reflect.TypeOf(common.FieldError{}), "json")
+	errEnvelope := envelope(&Schema{Nullable: true})
+	errEnvelope.Properties["details"] = &Schema{Type: "array", Items: &Schema{Ref: schemaRef + "FieldError"}}
+	errEnvelope.Properties["retryable"] = &Schema{Type: "boolean"}
+	d.Components.Responses = map[string]Response{
+		errorResponse: {Description: "Error, code is not 200", Content: jsonContent(errEnvelope)},
+	}
+	return d
+}
+
+func jsonContent(s *Schema) map[string]MediaType {
+	return map[string]MediaType{"application/json": {Schema: s}}
+}
+
+// envelope 与 handler.Response 一致
+func envelope(data *Schema) *Schema {
+	return &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"data":       data,
+			"code":       {Type: "integer"},
+			"msg":        {Type: "string"},
+			"request_id": {Type: "string"},
+		},
+		Required: []string{"data", "code", "msg", "request_id"},
+	}
+}
+
+type generator struct {
+	schemas map[string]*Schema
+}
+
+// SchemaOf 按 tag（json 或 form）生成类型的 schema，结构体直接展开，其中引用的具名结构体不展开
+func SchemaOf(t reflect.Type, tag string) *Schema {
+	g := &generator{schemas: make(map[string]*Schema)}
+	s := g.schema(t, tag)
+	if s.Ref != "" {
+		return g.schemas[strings.TrimPrefix(s.Ref, schemaRef)]
+	}
+	return s
+}
+
+// parameters 按 form 标签生成 query 参数
+func (g *generator) parameters(t reflect.Type) []Parameter {
+	var ret []Parameter
+	eachField(t, "form", func(name string, f reflect.StructField) {
+		s := g.schema(f.Type, "form")
+		required := applyBinding(s, f.Tag.Get("binding"))
+		ret = append(ret, Parameter{Name: name, In: "query", Required: required, Schema: s})
+	})
+	return ret
+}
+
+func (g *generator) schema(t reflect.Type, tag string) *Schema {
+	if t.Kind() == reflect.Pointer {
+		s := g.schema(t.Elem(), tag)
+		s.Nullable = true
+		return s
+	}
+	if t == reflect.TypeOf(time.Time{}) {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		zero := 0.0
+		return &Schema{Type: "integer", Format: "int64", Minimum: &zero}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: g.schema(t.Elem(), tag)}
+	case reflect.Map:
+		return &Schema{Type: "object"}
+	case reflect.Struct:
+		return g.object(t, tag)
+	}
+	// any 等无法确定类型的字段
+	return &Schema{}
+}
+
+// object 具名结构体放到 components 中引用，匿名结构体直接展开
+func (g *generator) object(t reflect.Type, tag string) *Schema {
+	name := t.Name()
+	if name != "" {
+		if _, ok := g.schemas[name]; ok {
+			return &Schema{Ref: schemaRef + name}
+		}
+		// 先占位，避免递归引用
+		g.schemas[name] = &Schema{Type: "object"}
+	}
+
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	eachField(t, tag, func(field string, f reflect.StructField) {
+		fs := g.schema(f.Type, tag)
+		if applyBinding(fs, f.Tag.Get("binding")) {
+			s.Required = append(s.Required, field)
+		}
+		s.Properties[field] = fs
+	})
+
+	if name == "" {
+		return s
+	}
+	g.schemas[name] = s
+	return &Schema{Ref: schemaRef + name}
+}
+
+// eachField 遍历导出字段，字段名取 tag，没有 tag 时使用字段名（与 encoding/json 一致）
+func eachField(t reflect.Type, tag string, fn func(name string, f reflect.StructField)) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name := strings.Split(f.Tag.Get(tag), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			if tag == "form" {
+				continue
+			}
+			name = f.Name
+		}
+		fn(name, f)
+	}
+}
+
+// applyBinding 把 binding 标签转换为 schema 约束，返回是否必填
+func applyBinding(s *Schema, binding string) bool {
+	required := false
+	for _, rule := range strings.Split(binding, ",") {
+		key, param, _ := strings.Cut(rule, "=")
+		switch key {
+		case "required":
+			required = true
+		case "min", "gte":
+			setBound(s, param, true)
+		case "max", "lte":
+			setBound(s, param, false)
+		case "oneof":
+			for _, e := range strings.Fields(param) {
+				if v, ok := enumValue(s.Type, e); ok {
+					s.Enum = append(s.Enum, v)
+				}
+			}
+		}
+	}
+	return required
+}
+
+// setBound min/max 对字符串、数组限制长度，对数字限制取值
+func setBound(s *Schema, param string, min bool) {
+	switch s.Type {
+	case "string", "array":
+		n, err := strconv.Atoi(param)
+		if err != nil {
+			return
+		}
+		switch {
+		case s.Type == "string" && min:
+			s.MinLength = &n
+		case s.Type == "string":
+			s.MaxLength = &n
+		case min:
+			s.MinItems = &n
+		default:
+			s.MaxItems = &n
+		}
+	case "integer", "number":
+		v, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			return
+		}
+		if min {
+			s.Minimum = &v
+		} else {
+			s.Maximum = &v
+		}
+	}
+}
+
+// enumValue 按字段类型解析 oneof 的取值
+func enumValue(typ, v string) (any, bool) {
+	switch typ {
+	case "integer":
+		n, err := strconv.ParseInt(v, 10, 64)
+		return n, err == nil
+	case "number":
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	}
+	return v, true
+}
@@ -7,6 +7,7 @@ import (
 	"web/common"
 	"web/context"
 	"web/logger"
+	"web/web/api"
 
 	"github.com/gin-gonic/gin"
 	"github.com/pquerna/ffjson/ffjson"
@@ -25,6 +26,9 @@ type Response struct {
 	Code      int    `json:"code"`
 	Msg       string `json:"msg"`
 	RequestID string `json:"request_id"`
+	Details   any    `json:"details,omitempty"`
+	// 稍后重试可能成功的错误为 true
+	Retryable bool `json:"retryable,omitempty"`
 }
 
 func (r *Response) InitCode(code int) {
@@ -39,12 +43,15 @@ func (r *Response) ToStruct(obj any) error {
 
 func render(c *gin.Context, v any, err error) {
 	requestId := context.GetRequestID(c)
-	resp := Response{Data: v, Code: common.SUCCESS, Msg: common.GetMsg(common.SUCCESS)}
+	status := http.StatusOK
+	resp := Response{Data: v, Code: common.SUCCESS, Msg: common.GetMsg(common.SUCCESS), RequestID: requestId}
 	if err != nil {
-		if tem, ok := err.(*common.Error); ok {
-			resp = Response{Data: v, Code: tem.Code, Msg: tem.Error(), RequestID: requestId}
-		} else {
-			resp = Response{Data: v, Code: common.Unknown, Msg: common.GetMsg(common.Unknown), RequestID: requestId}
+		e := common.FromError(err)
+		status = e.Status()
+		resp = Response{Data: v, Code: e.Code, Msg: e.Msg, RequestID: requestId, Details: e.Details, Retryable: e.Retryable()}
+		if status >= http.StatusInternalServerError {
+			// 原始错误只写日志，不返回给客户端
+			logger.Errorf("[Request ID: %s] request failed. [code:%d] [err:%v]", requestId, e.Code, err)
 		}
 	}
 
@@ -53,14 +60,25 @@ func render(c *gin.Context, v any, err error) {
 
 	logger.Infof("\033[0;32m [SMART REQUEST OUT]\033[0m [Request ID: %s] [Processing time:%6d ms] [res: %s]", requestId, time.Since(start).Milliseconds(), string(res))
 
-	c.JSON(http.StatusOK, resp)
+	c.JSON(status, resp)
 
 }
 
+// Abort 用于中间件，按错误码返回错误并中止后续处理
+func Abort(c *gin.Context, err error) {
+	render(c, nil, err)
+	c.Abort()
+}
+
 type (
 	TRPathParamHandlerFunc[T any, R any] func(ctx *gin.Context, t *T) (R, error)
 )
 
+// Handle 按 api 中的声明注册处理函数
+func Handle[T any, R any](r gin.IRoutes, route api.Route[T, R], handler TRPathParamHandlerFunc[T, R]) {
+	r.Handle(route.Method, route.Path, TRPathParamHandler(handler))
+}
+
 func TRPathParamHandler[T any, R any](
 	handler TRPathParamHandlerFunc[T, R],
 ) gin.HandlerFunc {
@@ -72,8 +90,8 @@ func handlerWithContext[T any, R any](
 ) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		t := new(T)
-		if err := c.ShouldBind(&t); err != nil {
-			render(c, nil, common.New(common.ParamsErr))
+		if err := bindAndValidate(c, t); err != nil {
+			render(c, nil, err)
 			return
 		}
 		switch handler := handlerFunc.(type) {
@@ -96,8 +114,8 @@ func handlerWithNudeContext[T any, R any](
 ) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		t := new(T)
-		if err := c.ShouldBind(&t); err != nil {
-			renderNude(c, nil, common.New(common.ParamsErr))
+		if err := bindAndValidate(c, t); err != nil {
+			renderNude(c, nil, err)
 			return
 		}
 		switch handler := handlerFunc.(type) {
@@ -110,6 +128,10 @@ func handlerWithNudeContext[T any, R any](
 }
 
 func renderNude(c *gin.Context, v any, err error) {
+	status := http.StatusOK
+	if err != nil {
+		status = common.FromError(err).Status()
+	}
 	resp := v
-	c.JSON(http.StatusOK, resp)
+	c.JSON(status, resp)
 }
@@ -0,0 +1,121 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"web/common"
+	"web/logger"
+	"web/web/handler"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+func init() {
+	logger.ErrorLogger = zap.NewNop().Sugar()
+	gin.SetMode(gin.TestMode)
+}
+
+type req struct {
+	Block uint `form:"block" binding:"required"`
+}
+
+func serve(t *testing.T, url string) (*httptest.ResponseRecorder, handler.Response) {
+	r := gin.New()
+	r.GET("/test", handler.TRPathParamHandler(func(c *gin.Context, t *req) (string, error) {
+		switch t.Block {
+		case 1:
+			return "", common.New(common.FileNotExist)
+		case 2:
+			return "", common.Wrap(common.DBErr, errors.New("connection refused"))
+		}
+		return "ok", nil
+	}))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, url, nil))
+	var resp handler.Response
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	return w, resp
+}
+
+func TestRenderStatus(t *testing.T) {
+	cases := []struct {
+		url    string
+		status int
+		code   int
+	}{
+		{"/test?block=3", http.StatusOK, common.SUCCESS},
+		{"/test", http.StatusBadRequest, common.ParamsErr},
+		{"/test?block=1", http.StatusNotFound, common.FileNotExist},
+		{"/test?block=2", http.StatusInternalServerError, common.DBErr},
+	}
+	for _, c := range cases {
+		w, resp := serve(t, c.url)
+		if w.Code != c.status || resp.Code != c.code {
+			t.Errorf("%s: want %d/%d got %d/%d", c.url, c.status, c.code, w.Code, resp.Code)
+		}
+		if resp.Msg != common.GetMsg(c.code) {
+			t.Errorf("%s: cause should not be exposed, got msg %q", c.url, resp.Msg)
+		}
+	}
+}
+
+type rangeReq struct {
+	From  uint   `form:"from"`
+	To    uint   `form:"to"`
+	Limit int    `form:"limit" binding:"omitempty,max=10"`
+	Sort  string `form:"sort" binding:"omitempty,oneof=asc desc"`
+}
+
+func (r *rangeReq) Validate() error {
+	if r.From > r.To {
+		return common.FieldError{Field: "to", Rule: "gtefield", Message: "to must be greater than or equal to from"}
+	}
+	return nil
+}
+
+func details(t *testing.T, url string) []common.FieldError {
+	r := gin.New()
+	r.GET("/range", handler.TRPathParamHandler(func(c *gin.Context, t *rangeReq) (string, error) {
+		return "ok", nil
+	}))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, url, nil))
+
+	var resp struct {
+		Code    int                 `json:"code"`
+		Details []common.FieldError `json:"details"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Details) > 0 && (w.Code != http.StatusBadRequest || resp.Code != common.ParamsErr) {
+		t.Fatalf("%s: unexpected response %d %+v", url, w.Code, resp)
+	}
+	return resp.Details
+}
+
+func TestValidationDetails(t *testing.T) {
+	if d := details(t, "/range?from=1&to=2"); len(d) != 0 {
+		t.Fatalf("unexpected details %+v", d)
+	}
+
+	d := details(t, "/range?limit=11&sort=up")
+	if len(d) != 2 || d[0].Field != "limit" || d[0].Rule != "max" || d[1].Field != "sort" || d[1].Rule != "oneof" {
+		t.Fatalf("unexpected details %+v", d)
+	}
+	if d[0].Message != "limit must be at most 10" {
+		t.Fatalf("unexpected message %q", d[0].Message)
+	}
+
+	d = details(t, "/range?from=3&to=2")
+	if len(d) != 1 || d[0].Field != "to" || d[0].Rule != "gtefield" {
+		t.Fatalf("unexpected details %+v", d)
+	}
+}
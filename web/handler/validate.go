@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"web/common"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// Validator 请求参数在 binding 校验之后的额外校验，用于字段之间的约束
+// 返回 common.FieldError(s) 时作为 details 返回，返回 *common.Error 时原样返回
+type Validator interface {
+	Validate() error
+}
+
+func init() {
+	// 校验错误中的字段名使用 form/json 标签，与请求参数一致
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(fieldName)
+	}
+}
+
+func fieldName(f reflect.StructField) string {
+	for _, key := range []string{"form", "json"} {
+		name := strings.Split(f.Tag.Get(key), ",")[0]
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return f.Name
+}
+
+// bindAndValidate 绑定请求参数并校验，失败时返回带 details 的 ParamsErr
+func bindAndValidate(c *gin.Context, t any) error {
+	if err := c.ShouldBind(t); err != nil {
+		return paramsErr(bindErrors(err))
+	}
+	v, ok := t.(Validator)
+	if !ok {
+		return nil
+	}
+	err := v.Validate()
+	if err == nil {
+		return nil
+	}
+
+	var ce *common.Error
+	if errors.As(err, &ce) {
+		return err
+	}
+	var fes common.FieldErrors
+	if errors.As(err, &fes) {
+		return paramsErr(fes)
+	}
+	var fe common.FieldError
+	if errors.As(err, &fe) {
+		return paramsErr(common.FieldErrors{fe})
+	}
+	return paramsErr(common.FieldErrors{{Rule: "validate", Message: err.Error()}})
+}
+
+func paramsErr(details common.FieldErrors) error {
+	return common.New(common.ParamsErr).(*common.Error).WithDetails(details)
+}
+
+// bindErrors 把 binding 的错误转换为字段错误
+func bindErrors(err error) common.FieldErrors {
+	var ves validator.ValidationErrors
+	if errors.As(err, &ves) {
+		ret := make(common.FieldErrors, 0, len(ves))
+		for _, fe := range ves {
+			field := fieldPath(fe.Namespace())
+			ret = append(ret, common.FieldError{
+				Field:   field,
+				Rule:    fe.Tag(),
+				Message: ruleMessage(field, fe.Tag(), fe.Param()),
+			})
+		}
+		return ret
+	}
+
+	var te *json.UnmarshalTypeError
+	if errors.As(err, &te) {
+		return common.FieldErrors{{
+			Field:   te.Field,
+			Rule:    "type",
+			Message: fmt.Sprintf("%s must be %s", te.Field, te.Type.String()),
+		}}
+	}
+	return common.FieldErrors{{Rule: "bind", Message: err.Error()}}
+}
+
+// fieldPath 去掉最外层的结构体名，例如 WebListReq.limit -> limit
+func fieldPath(namespace string) string {
+	if i := strings.Index(namespace, "."); i >= 0 {
+		return namespace[i+1:]
+	}
+	return namespace
+}
+
+func ruleMessage(field, rule, param string) string {
+	switch rule {
+	case "required":
+		return field + " is required"
+	case "min", "gte":
+		return fmt.Sprintf("%s must be at least %s", field, param)
+	case "max", "lte":
+		return fmt.Sprintf("%s must be at most %s", field, param)
+	case "gt":
+		return fmt.Sprintf("%s must be greater than %s", field, param)
+	case "lt":
+		return fmt.Sprintf("%s must be less than %s", field, param)
+	case "len":
+		return fmt.Sprintf("%s must have length %s", field, param)
+	case "oneof":
+		return fmt.Sprintf("%s must be one of [%s]", field, param)
+	}
+	if param != "" {
+		return fmt.Sprintf("%s failed on the %s=%s rule", field, rule, param)
+	}
+	return fmt.Sprintf("%s failed on the %s rule", field, rule)
+}
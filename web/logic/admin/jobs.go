@@ -0,0 +1,111 @@
+package admin
+
+import (
+	"errors"
+	"web/common"
+	webctx "web/context"
+	"web/jobs"
+	"web/web/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListJobs 返回所有任务的状态、最近执行记录与下一次执行时间
+func ListJobs(c *gin.Context, req *models.ListJobsReq) (models.ListJobsResp, error) {
+	var ret models.ListJobsResp
+
+	s := jobs.GetScheduler()
+	if s == nil {
+		return ret, common.New(common.JobDisable)
+	}
+	ret.Leader = s.Leading()
+	ret.List = make([]models.JobStatus, 0)
+	for _, st := range s.List() {
+		ret.List = append(ret.List, jobStatus(st))
+	}
+	return ret, nil
+}
+
+// TriggerJob 立即执行一次任务
+func TriggerJob(c *gin.Context, req *models.TriggerJobReq) (models.TriggerJobResp, error) {
+	var ret models.TriggerJobResp
+
+	s := jobs.GetScheduler()
+	if s == nil {
+		return ret, common.New(common.JobDisable)
+	}
+	if err := s.Trigger(req.Name); err != nil {
+		return ret, jobErr(err)
+	}
+	webctx.Logger(c).Infof("job triggered. [job:%s]", req.Name)
+	return ret, nil
+}
+
+// PauseJob 暂停或恢复任务的定时执行
+func PauseJob(c *gin.Context, req *models.PauseJobReq) (models.PauseJobResp, error) {
+	var ret models.PauseJobResp
+
+	s := jobs.GetScheduler()
+	if s == nil {
+		return ret, common.New(common.JobDisable)
+	}
+	if err := s.Pause(req.Name, req.Paused); err != nil {
+		return ret, jobErr(err)
+	}
+	st, err := s.Get(req.Name)
+	if err != nil {
+		return ret, jobErr(err)
+	}
+	webctx.Logger(c).Infof("job pause changed. [job:%s] [paused:%v]", req.Name, req.Paused)
+	ret.Job = jobStatus(st)
+	return ret, nil
+}
+
+func jobErr(err error) error {
+	switch {
+	case errors.Is(err, jobs.ErrJobNotFound):
+		return common.New(common.JobNotExist)
+	case errors.Is(err, jobs.ErrJobRunning):
+		return common.New(common.JobRunning)
+	case errors.Is(err, jobs.ErrNotStarted):
+		return common.New(common.JobDisable)
+	case errors.Is(err, jobs.ErrNotLeader):
+		return common.New(common.JobNotLeader)
+	}
+	return common.Wrap(common.ERROR, err)
+}
+
+func jobStatus(st jobs.Status) models.JobStatus {
+	ret := models.JobStatus{
+		Name:       st.Name,
+		Schedule:   st.Schedule,
+		LeaderOnly: st.LeaderOnly,
+		Paused:     st.Paused,
+		Running:    st.Running,
+		Failures:   st.Failures,
+		Skipped:    st.Skipped,
+		History:    make([]models.JobRun, 0, len(st.History)),
+	}
+	if !st.NextRun.IsZero() {
+		ret.NextRun = st.NextRun.Unix()
+	}
+	for _, r := range st.History {
+		ret.History = append(ret.History, jobRun(r))
+	}
+	if st.LastRun != nil {
+		last := jobRun(*st.LastRun)
+		ret.LastRun = &last
+	}
+	return ret
+}
+
+func jobRun(r jobs.Run) models.JobRun {
+	return models.JobRun{
+		Trigger:    r.Trigger,
+		RequestID:  r.RequestID,
+		Start:      r.Start.Unix(),
+		DurationMs: r.Duration.Milliseconds(),
+		Err:        r.Err,
+		Panic:      r.Panic,
+	}
+}
@@ -0,0 +1,108 @@
+package merkle
+
+import (
+	"errors"
+	"fmt"
+	"web/common"
+	webctx "web/context"
+	"web/repository/chain"
+	"web/repository/merklefetch"
+	"web/repository/merklestore"
+	"web/repository/state"
+	"web/web/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BuildMerkle 根据区块的 ins 与 trx 记录构建 Merkle 树并保存到本地
+func BuildMerkle(c *gin.Context, req *models.BuildMerkleRequest) (models.BuildMerkleResponse, error) {
+	var ret models.BuildMerkleResponse
+
+	f := merklestore.NewFile(req.Block, req.Ins, req.Trx)
+	f.Hash, f.PrevHash = req.Hash, req.PrevHash
+
+	var path string
+	reorg, err := chain.Commit(c, req.Block, req.Hash, req.PrevHash, func(*chain.Reorg) error {
+		p, err := merklestore.GetLocal().Put(f)
+		if err != nil {
+			return common.Wrap(common.FileSaveErr, fmt.Errorf("save merkle file %d: %w", req.Block, err))
+		}
+		path = p
+		return nil
+	})
+	if err != nil {
+		var ce *common.Error
+		if errors.As(err, &ce) {
+			return ret, err
+		}
+		return ret, common.Wrap(common.FileSaveErr, fmt.Errorf("rewind chain reorg at block %d: %w", req.Block, err))
+	}
+	if reorg != nil && reorg.Next() < req.Block {
+		// 分叉点之后的区块需要按新链重新推送
+		return ret, common.New(common.ChainReorg)
+	}
+
+	if err = state.GetState().AdvanceLocal(state.FirstLocalHeight, merklestore.GetLocal().Has); err != nil {
+		webctx.Logger(c).Errorf("update local last push failed. [block:%d] [err:%v]", req.Block, err)
+	}
+
+	webctx.Logger(c).Infof("build merkle success. [block:%d] [root:%s] [path:%s]", req.Block, f.Root, path)
+	ret.Root = f.Root
+	return ret, nil
+}
+
+// GetMerkleFile 返回区块 Merkle 文件的路径
+func GetMerkleFile(c *gin.Context, req *models.GetMerkleFileRequest) (models.GetMerkleFileResp, error) {
+	var ret models.GetMerkleFileResp
+
+	if req.Remote {
+		return getRemoteMerkleFile(c, req)
+	}
+
+	path, err := merklestore.GetLocal().Path(req.Block)
+	if err != nil {
+		return ret, storeErr(req.Block, err)
+	}
+
+	ret.Path = path
+	return ret, nil
+}
+
+// GetLastPush 返回本地连续构建的最高区块以及远端看到的最高区块
+func GetLastPush(c *gin.Context, req *models.GetLastPushRequest) (models.GetLastPushResponse, error) {
+	var ret models.GetLastPushResponse
+
+	st := state.GetState().Get()
+	ret.LocalLastPush = st.LocalLastPush
+	ret.RemoteLastPush = st.RemoteLastPush
+	return ret, nil
+}
+
+// getRemoteMerkleFile 从远端拉取（或确认未变化）后返回本地镜像路径
+func getRemoteMerkleFile(c *gin.Context, req *models.GetMerkleFileRequest) (models.GetMerkleFileResp, error) {
+	var ret models.GetMerkleFileResp
+
+	f := merklefetch.GetFetcher()
+	if f == nil {
+		return ret, common.New(common.RemoteDisable)
+	}
+
+	path, err := f.Fetch(c, req.Block)
+	if err != nil {
+		if errors.Is(err, merklefetch.ErrNotExist) {
+			return ret, common.New(common.FileNotExist)
+		}
+		return ret, common.Wrap(common.RemoteErr, fmt.Errorf("fetch remote merkle file %d: %w", req.Block, err))
+	}
+
+	ret.Path = path
+	return ret, nil
+}
+
+// storeErr 把存储层错误转换为接口错误码
+func storeErr(block uint, err error) error {
+	if errors.Is(err, merklestore.ErrNotExist) {
+		return common.New(common.FileNotExist)
+	}
+	return common.Wrap(common.FileReadErr, fmt.Errorf("load merkle file %d: %w", block, err))
+}
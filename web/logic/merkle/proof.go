@@ -0,0 +1,85 @@
+package merkle
+
+import (
+	"encoding/hex"
+	"web/common"
+	"web/repository/merklestore"
+	"web/utils/merkle"
+	"web/web/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	positionLeft  = "left"
+	positionRight = "right"
+)
+
+// GetMerkleProof 返回单条 ins/trx 记录的包含证明
+// leaf 既可以是叶子哈希，也可以是原始记录
+func GetMerkleProof(c *gin.Context, req *models.GetMerkleProofRequest) (models.GetMerkleProofResponse, error) {
+	var ret models.GetMerkleProofResponse
+
+	f, err := merklestore.GetLocal().Get(req.Block)
+	if err != nil {
+		return ret, storeErr(req.Block, err)
+	}
+
+	index := f.LeafIndex(req.Leaf)
+	if index < 0 {
+		index = f.LeafIndex(hex.EncodeToString(merkle.LeafHash([]byte(req.Leaf))))
+	}
+	if index < 0 {
+		return ret, common.New(common.LeafNotExist)
+	}
+
+	tree := merkle.New(f.LeafHashes())
+	path, _ := tree.Proof(index)
+
+	leaf := f.Leaf(index)
+	ret.Block = f.Block
+	ret.Root = tree.RootHex()
+	ret.Leaf = leaf.Hash
+	ret.Data = leaf.Data
+	ret.Index = index
+	ret.Path = make([]models.MerkleProof, 0, len(path))
+	for _, n := range path {
+		p := models.MerkleProof{Hash: hex.EncodeToString(n.Hash), Position: positionRight}
+		if n.Left {
+			p.Position = positionLeft
+		}
+		ret.Path = append(ret.Path, p)
+	}
+	return ret, nil
+}
+
+// VerifyMerkleProof 无状态校验包含证明，不读取本地文件
+// 叶子哈希由原始记录计算，避免把内部节点当作叶子绕过 0x00/0x01 前缀区分
+func VerifyMerkleProof(c *gin.Context, req *models.VerifyMerkleProofRequest) (models.VerifyMerkleProofResponse, error) {
+	var ret models.VerifyMerkleProofResponse
+
+	leaf := merkle.LeafHash([]byte(req.Data))
+	root, err := hex.DecodeString(req.Root)
+	if err != nil {
+		return ret, common.New(common.ParamsErr)
+	}
+
+	path := make([]merkle.ProofNode, 0, len(req.Path))
+	for _, p := range req.Path {
+		h, err := hex.DecodeString(p.Hash)
+		if err != nil {
+			return ret, common.New(common.ParamsErr)
+		}
+		switch p.Position {
+		case positionLeft:
+			path = append(path, merkle.ProofNode{Hash: h, Left: true})
+		case positionRight:
+			path = append(path, merkle.ProofNode{Hash: h})
+		default:
+			return ret, common.New(common.ParamsErr)
+		}
+	}
+
+	ret.Valid = merkle.Verify(leaf, path, root)
+	return ret, nil
+}
@@ -1,16 +1,16 @@
 package ping
 
 import (
-	"web/logger"
+	webctx "web/context"
 	"web/web/models"
 
 	"github.com/gin-gonic/gin"
 )
 
-func GetPingInfo(c *gin.Context, req *models.PingReq) (any, error) {
+func GetPingInfo(c *gin.Context, req *models.PingReq) (models.PingResp, error) {
 	var ret models.PingResp
 	ret.Ping = `pong`
-	logger.Infof("ping running. [ret:%s]", ret)
+	webctx.Logger(c).Infof("ping running. [ret:%s]", ret)
 
 	return ret, nil
 }
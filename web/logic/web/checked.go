@@ -0,0 +1,41 @@
+package web
+
+import (
+	"web/common"
+	"web/constant"
+	"web/dao"
+	"web/repository/pg"
+	"web/web/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+const checkedLimit = 100
+
+// GetChecked 返回 checker 最近检查的区块
+func GetChecked(c *gin.Context, req *models.WebCheckedReq) (models.WebCheckedResp, error) {
+	var ret models.WebCheckedResp
+
+	db, err := pg.GetDB(constant.DBNameMain)
+	if err != nil {
+		return ret, common.Wrap(common.DBErr, err)
+	}
+	db = db.WithContext(c)
+
+	checks, err := dao.ListLatestBlockChecks(db, checkedLimit)
+	if err != nil {
+		return ret, common.Wrap(common.DBErr, err)
+	}
+
+	ret.List = make([]models.WebCheckedResult, 0, len(checks))
+	for _, check := range checks {
+		ret.List = append(ret.List, models.WebCheckedResult{
+			Number:     int(check.Number),
+			Hash:       check.Hash,
+			Status:     check.Status,
+			LocalRoot:  check.LocalRoot,
+			RemoteRoot: check.RemoteRoot,
+		})
+	}
+	return ret, nil
+}
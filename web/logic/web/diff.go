@@ -0,0 +1,193 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"web/brc20"
+	"web/common"
+	webctx "web/context"
+	"web/repository/merklefetch"
+	"web/repository/merklestore"
+	"web/web/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DiffRecord 分组使用的记录字段
+type DiffRecord struct {
+	InscriptionID string `json:"inscription_id"`
+	Op            string `json:"op"`
+	Tick          string `json:"tick"`
+}
+
+// DiffResult 两侧叶子集合的差异
+type DiffResult struct {
+	// 只在本地 / 只在远端出现的原始记录
+	Local  []string
+	Remote []string
+	Groups []models.WebDiffGroup
+}
+
+// Diff 比较两个区块文件的叶子集合，ins 与 trx 分别比较
+// 同一条记录出现多次时按次数比较
+func Diff(local, remote *merklestore.File) DiffResult {
+	ret := DiffResult{Local: make([]string, 0), Remote: make([]string, 0)}
+	if local == nil {
+		local = &merklestore.File{}
+	}
+	if remote == nil {
+		remote = &merklestore.File{}
+	}
+
+	for _, pair := range [][2][]merklestore.Leaf{{local.Ins, remote.Ins}, {local.Trx, remote.Trx}} {
+		l, r := diffLeaves(pair[0], pair[1])
+		ret.Local = append(ret.Local, l...)
+		ret.Remote = append(ret.Remote, r...)
+	}
+	ret.Groups = groupRecords(ret.Local, ret.Remote)
+	return ret
+}
+
+// diffLeaves 返回只在 a 中和只在 b 中的记录
+func diffLeaves(a, b []merklestore.Leaf) ([]string, []string) {
+	count := make(map[string]int, len(b))
+	for _, l := range b {
+		count[l.Hash]++
+	}
+
+	onlyA := make([]string, 0)
+	for _, l := range a {
+		if count[l.Hash] > 0 {
+			count[l.Hash]--
+			continue
+		}
+		onlyA = append(onlyA, l.Data)
+	}
+
+	onlyB := make([]string, 0)
+	for _, l := range b {
+		if count[l.Hash] > 0 {
+			count[l.Hash]--
+			onlyB = append(onlyB, l.Data)
+		}
+	}
+	return onlyA, onlyB
+}
+
+// groupRecords 按 inscription_id、op、tick 分组，无法解析的记录归入空分组
+func groupRecords(local, remote []string) []models.WebDiffGroup {
+	groups := make(map[DiffRecord]*models.WebDiffGroup)
+	add := func(data string, isLocal bool) {
+		var r DiffRecord
+		_ = json.Unmarshal([]byte(data), &r)
+
+		g, ok := groups[r]
+		if !ok {
+			g = &models.WebDiffGroup{
+				InscriptionID: r.InscriptionID,
+				Op:            r.Op,
+				Tick:          r.Tick,
+				Local:         make([]string, 0),
+				Remote:        make([]string, 0),
+			}
+			groups[r] = g
+		}
+		if isLocal {
+			g.Local = append(g.Local, data)
+			if g.LocalCode == "" {
+				g.LocalCode = recordCode(data)
+			}
+		} else {
+			g.Remote = append(g.Remote, data)
+			if g.RemoteCode == "" {
+				g.RemoteCode = recordCode(data)
+			}
+		}
+	}
+	for _, d := range local {
+		add(d, true)
+	}
+	for _, d := range remote {
+		add(d, false)
+	}
+
+	ret := make([]models.WebDiffGroup, 0, len(groups))
+	for _, g := range groups {
+		ret = append(ret, *g)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].InscriptionID != ret[j].InscriptionID {
+			return ret[i].InscriptionID < ret[j].InscriptionID
+		}
+		if ret[i].Op != ret[j].Op {
+			return ret[i].Op < ret[j].Op
+		}
+		return ret[i].Tick < ret[j].Tick
+	})
+	return ret
+}
+
+// recordCode 记录无效的原因：Engine 结果中的 code，或者原始铭文内容的解析错误码
+func recordCode(data string) string {
+	var r struct {
+		Code string  `json:"code"`
+		P    *string `json:"p"`
+	}
+	if err := json.Unmarshal([]byte(data), &r); err != nil {
+		return brc20.ErrCodeJSON
+	}
+	if r.Code != "" || r.P == nil {
+		return r.Code
+	}
+	var pe *brc20.ParseError
+	if _, err := brc20.ParseInscription([]byte(data)); errors.As(err, &pe) {
+		return pe.Code
+	}
+	return ""
+}
+
+// GetDiff 比较区块本地与远端的记录
+func GetDiff(c *gin.Context, req *models.WebDiffReq) (models.WebDiffResp, error) {
+	var ret models.WebDiffResp
+
+	local, err := loadFile(merklestore.GetLocal(), req.Block)
+	if err != nil {
+		return ret, err
+	}
+
+	// 配置了远端时先刷新镜像，失败则使用已有镜像
+	if f := merklefetch.GetFetcher(); f != nil {
+		if _, err = f.Fetch(c, req.Block); err != nil && !errors.Is(err, merklefetch.ErrNotExist) {
+			webctx.Logger(c).Warnf("fetch remote merkle file failed, use mirror. [block:%d] [err:%v]", req.Block, err)
+		}
+	}
+	remote, err := loadFile(merklestore.GetRemote(), req.Block)
+	if err != nil {
+		return ret, err
+	}
+
+	d := Diff(local, remote)
+	ret.LocalExist = local != nil
+	ret.RemoteExist = remote != nil
+	ret.Local = d.Local
+	ret.Remote = d.Remote
+	ret.Groups = d.Groups
+	return ret, nil
+}
+
+// loadFile 文件不存在或没有配置该存储时返回 nil
+func loadFile(s *merklestore.Store, block uint) (*merklestore.File, error) {
+	if s == nil {
+		return nil, nil
+	}
+	f, err := s.Get(block)
+	if err == nil {
+		return f, nil
+	}
+	if errors.Is(err, merklestore.ErrNotExist) {
+		return nil, nil
+	}
+	return nil, common.Wrap(common.FileReadErr, fmt.Errorf("load merkle file %d from %s: %w", block, s.Root(), err))
+}
@@ -0,0 +1,64 @@
+package web
+
+import (
+	"time"
+	"web/common"
+	"web/constant"
+	"web/dao"
+	"web/repository/pg"
+	"web/web/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultListLimit = 100
+	sortDesc         = "desc"
+)
+
+// GetList 分页查询 checker 的对账结果
+func GetList(c *gin.Context, req *models.WebListReq) (models.WebListResp, error) {
+	var ret models.WebListResp
+
+	db, err := pg.GetDB(constant.DBNameMain)
+	if err != nil {
+		return ret, common.Wrap(common.DBErr, err)
+	}
+	db = db.WithContext(c)
+
+	filter := dao.BlockCheckFilter{
+		Status: req.Status,
+		From:   req.From,
+		To:     req.To,
+		Cursor: req.Cursor,
+		Desc:   req.Sort == sortDesc,
+		Limit:  req.Limit,
+	}
+	if req.Block > 0 {
+		filter.From, filter.To = req.Block, req.Block
+	}
+	if req.Since > 0 {
+		filter.Since = time.Unix(req.Since, 0)
+	}
+	if filter.Limit == 0 {
+		filter.Limit = defaultListLimit
+	}
+
+	checks, err := dao.ListBlockChecks(db, filter)
+	if err != nil {
+		return ret, common.Wrap(common.DBErr, err)
+	}
+
+	ret.List = make([]models.WebListResult, 0, len(checks))
+	for _, check := range checks {
+		ret.List = append(ret.List, models.WebListResult{
+			Number: int(check.Number),
+			Hash:   check.Hash,
+			Status: check.Status,
+		})
+	}
+	if len(checks) == filter.Limit {
+		ret.NextCursor = checks[len(checks)-1].Number
+	}
+	return ret, nil
+}
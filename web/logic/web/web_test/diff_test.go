@@ -0,0 +1,60 @@
+package web_test
+
+import (
+	"encoding/hex"
+	"testing"
+	"web/repository/merklestore"
+	"web/utils/merkle"
+	"web/web/logic/web"
+)
+
+func leaves(records ...string) []merklestore.Leaf {
+	ret := make([]merklestore.Leaf, 0, len(records))
+	for _, r := range records {
+		ret = append(ret, merklestore.Leaf{Hash: hex.EncodeToString(merkle.LeafHash([]byte(r))), Data: r})
+	}
+	return ret
+}
+
+func TestDiff(t *testing.T) {
+	a := `{"inscription_id":"i0","op":"mint","tick":"ordi","amt":"1"}`
+	b := `{"inscription_id":"i0","op":"mint","tick":"ordi","amt":"2"}`
+	c := `{"inscription_id":"i1","op":"transfer","tick":"sats"}`
+
+	local := &merklestore.File{Ins: leaves(a, c, c), Trx: leaves(c)}
+	remote := &merklestore.File{Ins: leaves(b, c), Trx: leaves(c)}
+
+	d := web.Diff(local, remote)
+	if len(d.Local) != 2 || len(d.Remote) != 1 || d.Remote[0] != b {
+		t.Fatalf("unexpected diff local %v remote %v", d.Local, d.Remote)
+	}
+	if len(d.Groups) != 2 {
+		t.Fatalf("unexpected groups %+v", d.Groups)
+	}
+	g := d.Groups[0]
+	if g.InscriptionID != "i0" || len(g.Local) != 1 || len(g.Remote) != 1 {
+		t.Fatalf("unexpected group %+v", g)
+	}
+}
+
+func TestDiffMissingSide(t *testing.T) {
+	d := web.Diff(&merklestore.File{Ins: leaves("x")}, nil)
+	if len(d.Local) != 1 || len(d.Remote) != 0 {
+		t.Fatalf("unexpected diff local %v remote %v", d.Local, d.Remote)
+	}
+}
+
+func TestDiffCodes(t *testing.T) {
+	// 原始铭文内容按解析器判断，Engine 结果直接使用 code
+	raw := `{"p":"brc-20","op":"mint","tick":"ordi","amt":1}`
+	res := `{"inscription_id":"i0","op":"mint","tick":"ordi","valid":4,"code":"tick_length"}`
+
+	d := web.Diff(&merklestore.File{Ins: leaves(raw)}, &merklestore.File{Ins: leaves(res)})
+	codes := map[string]bool{}
+	for _, g := range d.Groups {
+		codes[g.LocalCode+"|"+g.RemoteCode] = true
+	}
+	if !codes["field_not_string|"] || !codes["|tick_length"] {
+		t.Fatalf("unexpected codes %+v", d.Groups)
+	}
+}
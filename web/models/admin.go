@@ -0,0 +1,59 @@
+package models
+
+type (
+	ListJobsReq struct {
+	}
+
+	ListJobsResp struct {
+		// 本实例是否是 leader，没有配置选举时为 true
+		Leader bool        `json:"leader"`
+		List   []JobStatus `json:"list"`
+	}
+
+	JobStatus struct {
+		Name     string `json:"name"`
+		Schedule string `json:"schedule"`
+		// 只在 leader 上执行
+		LeaderOnly bool `json:"leader_only"`
+		Paused     bool `json:"paused"`
+		Running    bool `json:"running"`
+		// unix 秒，0 表示不会再定时执行
+		NextRun  int64    `json:"next_run"`
+		LastRun  *JobRun  `json:"last_run,omitempty"`
+		Failures int      `json:"failures"`
+		Skipped  int      `json:"skipped"`
+		History  []JobRun `json:"history"`
+	}
+
+	JobRun struct {
+		// schedule 或 manual
+		Trigger   string `json:"trigger"`
+		RequestID string `json:"request_id"`
+		// unix 秒
+		Start      int64  `json:"start"`
+		DurationMs int64  `json:"duration_ms"`
+		Err        string `json:"err,omitempty"`
+		Panic      bool   `json:"panic,omitempty"`
+	}
+)
+
+type (
+	TriggerJobReq struct {
+		Name string `json:"name" binding:"required"`
+	}
+
+	TriggerJobResp struct {
+	}
+)
+
+type (
+	PauseJobReq struct {
+		Name string `json:"name" binding:"required"`
+		// false 时恢复
+		Paused bool `json:"paused"`
+	}
+
+	PauseJobResp struct {
+		Job JobStatus `json:"job"`
+	}
+)
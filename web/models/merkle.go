@@ -1,13 +1,25 @@
 package models
 
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"web/common"
+)
+
 type (
 	BuildMerkleRequest struct {
-		Block uint     `form:"block"`
-		Ins   []string `form:"ins"`
-		Trx   []string `form:"trx"`
+		Block uint     `form:"block" json:"block"`
+		Ins   []string `form:"ins" json:"ins"`
+		Trx   []string `form:"trx" json:"trx"`
+		// 区块哈希与父区块哈希，传入后用于检测链重组
+		Hash     string `form:"hash" json:"hash"`
+		PrevHash string `form:"prev_hash" json:"prev_hash"`
 	}
 
-	BuildMerkleResponse struct{}
+	BuildMerkleResponse struct {
+		Root string `json:"root"`
+	}
 )
 
 type (
@@ -30,3 +42,60 @@ type (
 		RemoteLastPush uint `json:"remote_last_push"`
 	}
 )
+
+type (
+	GetMerkleProofRequest struct {
+		Block uint   `form:"block"`
+		Leaf  string `form:"leaf" binding:"required"`
+	}
+
+	GetMerkleProofResponse struct {
+		Block uint          `json:"block"`
+		Root  string        `json:"root"`
+		Leaf  string        `json:"leaf"`
+		Data  string        `json:"data"`
+		Index int           `json:"index"`
+		Path  []MerkleProof `json:"path"`
+	}
+
+	MerkleProof struct {
+		Hash     string `json:"hash"`
+		Position string `json:"position"`
+	}
+)
+
+type (
+	VerifyMerkleProofRequest struct {
+		// 原始 ins/trx 记录，叶子哈希由服务端计算
+		Data string        `form:"data" json:"data" binding:"required"`
+		Root string        `form:"root" json:"root" binding:"required"`
+		Path []MerkleProof `form:"path" json:"path"`
+	}
+
+	VerifyMerkleProofResponse struct {
+		Valid bool `json:"valid"`
+	}
+)
+
+// Validate 哈希必须是 32 字节的 hex，position 只能是 left 或 right
+func (r *VerifyMerkleProofRequest) Validate() error {
+	var errs common.FieldErrors
+	checkHash := func(field, h string) {
+		if b, err := hex.DecodeString(h); err != nil || len(b) != sha256.Size {
+			errs = append(errs, common.FieldError{Field: field, Rule: "sha256", Message: field + " must be a hex encoded sha256 hash"})
+		}
+	}
+
+	checkHash("root", r.Root)
+	for i, p := range r.Path {
+		checkHash(fmt.Sprintf("path[%d].hash", i), p.Hash)
+		if p.Position != "left" && p.Position != "right" {
+			field := fmt.Sprintf("path[%d].position", i)
+			errs = append(errs, common.FieldError{Field: field, Rule: "oneof", Message: field + " must be one of [left right]"})
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
@@ -1,5 +1,7 @@
 package models
 
+import "web/common"
+
 type (
 	WebCheckedReq struct {
 	}
@@ -9,19 +11,33 @@ type (
 	}
 
 	WebCheckedResult struct {
-		Number int    `json:"number"`
-		Hash   string `json:"hash"`
-		Status int    `json:"status"`
+		Number     int    `json:"number"`
+		Hash       string `json:"hash"`
+		Status     int    `json:"status"`
+		LocalRoot  string `json:"local_root"`
+		RemoteRoot string `json:"remote_root"`
 	}
 )
 
 type (
 	WebListReq struct {
+		// 只查询单个区块
 		Block uint `form:"block"`
+		// 状态过滤，可传多个
+		Status []int `form:"status"`
+		From   uint  `form:"from"`
+		To     uint  `form:"to"`
+		// unix 秒，只返回该时间之后检查的区块
+		Since  int64  `form:"since"`
+		Cursor uint   `form:"cursor"`
+		Limit  int    `form:"limit" binding:"omitempty,min=1,max=1000"`
+		Sort   string `form:"sort" binding:"omitempty,oneof=asc desc"`
 	}
 
 	WebListResp struct {
 		List []WebListResult `json:"list"`
+		// 下一页的游标，为 0 时没有更多数据
+		NextCursor uint `json:"next_cursor"`
 	}
 
 	WebListResult struct {
@@ -31,6 +47,14 @@ type (
 	}
 )
 
+// Validate from 不能大于 to
+func (r *WebListReq) Validate() error {
+	if r.From > 0 && r.To > 0 && r.From > r.To {
+		return common.FieldError{Field: "to", Rule: "gtefield", Message: "to must be greater than or equal to from"}
+	}
+	return nil
+}
+
 type (
 	WebDiffReq struct {
 		Block uint `form:"block"`
@@ -42,6 +66,19 @@ type (
 
 		Local  []string `json:"local"`
 		Remote []string `json:"remote"`
+
+		Groups []WebDiffGroup `json:"groups"`
+	}
+
+	WebDiffGroup struct {
+		InscriptionID string   `json:"inscription_id"`
+		Op            string   `json:"op"`
+		Tick          string   `json:"tick"`
+		Local         []string `json:"local"`
+		Remote        []string `json:"remote"`
+		// 记录无效时的 BRC-20 解析错误码（brc20.ErrCode*），有效或无法判断时为空
+		LocalCode  string `json:"local_code,omitempty"`
+		RemoteCode string `json:"remote_code,omitempty"`
 	}
 
 	WebDiffResult struct {
@@ -1,9 +1,20 @@
 package router
 
 import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"time"
+	"web/common"
+	"web/config"
 	"web/context"
+	"web/logger"
+	"web/web/api"
 	"web/web/handler"
+	"web/web/logic/admin"
+	"web/web/logic/merkle"
 	"web/web/logic/ping"
+	"web/web/logic/web"
 
 	"github.com/gin-gonic/gin"
 )
@@ -11,7 +22,11 @@ import (
 // validator web server router
 func InitRouter() *gin.Engine {
 	r := gin.New()
-	r.Use(gin.Logger())
+	// *gin.Context 的 Done/Deadline/Value 使用 c.Request.Context()
+	r.ContextWithFallback = true
+	r.Use(context.RequestIDMiddleware())
+	r.Use(context.RequestContext(config.Configure.ServerSetting.RequestTimeout * time.Second))
+	r.Use(gin.LoggerWithFormatter(accessLogFormatter))
 	r.Use(gin.Recovery())
 
 	// set request start
@@ -19,13 +34,68 @@ func InitRouter() *gin.Engine {
 		context.SetRequestTIme(ctx)
 	})
 
-	api := r.Group("/api")
-
 	// server test
-	pingGroup := api.Group("ping")
-	{
-		pingGroup.GET("", handler.TRPathParamHandler(ping.GetPingInfo))
+	handler.Handle(r, api.Ping, ping.GetPingInfo)
+
+	// merkle data
+	handler.Handle(r, api.BuildMerkle, merkle.BuildMerkle)
+	handler.Handle(r, api.GetMerkleProof, merkle.GetMerkleProof)
+	handler.Handle(r, api.VerifyMerkleProof, merkle.VerifyMerkleProof)
+	handler.Handle(r, api.GetMerkleFile, merkle.GetMerkleFile)
+	handler.Handle(r, api.GetLastPush, merkle.GetLastPush)
+
+	// validator web
+	handler.Handle(r, api.GetDiff, web.GetDiff)
+	handler.Handle(r, api.GetChecked, web.GetChecked)
+	handler.Handle(r, api.GetList, web.GetList)
+
+	// admin，未开启或没有配置 token 时不注册
+	if a := config.Configure.AdminSetting; a.Enabled {
+		if a.Token == "" {
+			logger.Errorf("admin is enabled without token, admin api is not registered")
+		} else {
+			g := r.Group("", adminAuth(a.Token))
+			handler.Handle(g, api.ListJobs, admin.ListJobs)
+			handler.Handle(g, api.TriggerJob, admin.TriggerJob)
+			handler.Handle(g, api.PauseJob, admin.PauseJob)
+		}
 	}
 
+	// 兼容旧的对比接口
+	handler.Handle(r, api.GetInsDiff, web.GetDiff)
+
+	// 接口文档，按 api 中的声明生成
+	doc := api.OpenAPI()
+	r.GET("/api/openapi.json", func(c *gin.Context) {
+		c.JSON(http.StatusOK, doc)
+	})
+
 	return r
 }
+
+// adminAuth 校验 Authorization: Bearer <token>
+func adminAuth(token string) gin.HandlerFunc {
+	want := []byte("Bearer " + token)
+	return func(c *gin.Context) {
+		if subtle.ConstantTimeCompare([]byte(c.GetHeader("Authorization")), want) != 1 {
+			handler.Abort(c, common.New(common.Unauthorized))
+			return
+		}
+		c.Next()
+	}
+}
+
+// accessLogFormatter gin 默认的访问日志格式，加上请求 ID
+func accessLogFormatter(param gin.LogFormatterParams) string {
+	requestId, _ := param.Keys[context.RequestId].(string)
+	return fmt.Sprintf("[GIN] %v | %3d | %13v | %15s | %-7s %#v | %s\n%s",
+		param.TimeStamp.Format("2006/01/02 - 15:04:05"),
+		param.StatusCode,
+		param.Latency,
+		param.ClientIP,
+		param.Method,
+		param.Path,
+		requestId,
+		param.ErrorMessage,
+	)
+}
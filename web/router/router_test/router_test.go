@@ -0,0 +1,33 @@
+package router_test
+
+import (
+	"testing"
+	"web/config"
+	"web/logger"
+	"web/web/api"
+	"web/web/router"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+func init() {
+	logger.ErrorLogger = zap.NewNop().Sugar()
+	gin.SetMode(gin.TestMode)
+}
+
+// 每个声明的接口都要注册处理函数，否则 client 与文档中有而服务端 404
+func TestAllRoutesRegistered(t *testing.T) {
+	config.Configure.AdminSetting = config.Admin{Enabled: true, Token: "secret"}
+	defer func() { config.Configure.AdminSetting = config.Admin{} }()
+
+	registered := map[string]bool{}
+	for _, r := range router.InitRouter().Routes() {
+		registered[r.Method+" "+r.Path] = true
+	}
+	for _, r := range api.Routes() {
+		if !registered[r.Method+" "+r.Path] {
+			t.Errorf("%s %s %s is not registered", r.Name, r.Method, r.Path)
+		}
+	}
+}